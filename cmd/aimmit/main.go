@@ -1,31 +1,118 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/rust17/AImmit/internal/ai"
+	"github.com/rust17/AImmit/internal/cache"
+	"github.com/rust17/AImmit/internal/config"
 	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
 	"github.com/rust17/AImmit/internal/summarizer"
 	"github.com/rust17/AImmit/internal/utils"
 )
 
 func main() {
+	// 先确定界面语言，参数说明也使用对应的语言
+	initLocale()
+
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		runCacheCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "model" {
+		runModelCommand(os.Args[2:])
+		return
+	}
+
+	// 读取配置文件，例如aimmit model use选择的默认模型
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLIConfigFailed, err))
+		cfg = &config.Config{}
+	}
+	// 配置文件中的语言作为--lang的默认值
+	defaultLang := ai.DefaultLang
+	if cfg.Lang != "" {
+		if err := ai.ValidateLang(cfg.Lang); err != nil {
+			fmt.Println(i18n.T(i18n.CLIConfigFailed, err))
+		} else {
+			defaultLang = cfg.Lang
+		}
+	}
+
 	// 定义命令行参数
-	format := flag.String("format", "conventional", "输出格式 (text, json, conventional)")
-	repoPath := flag.String("repo", ".", "Git仓库路径")
-	stagedOnly := flag.Bool("staged", true, "是否只分析已暂存的更改")
-	autoCommit := flag.Bool("auto-commit", false, "是否自动执行git commit")
-	enableDebug := flag.Bool("debug", false, "是否开启debug模式")
-	onlyPrompt := flag.Bool("only-prompt", false, "只显示prompt")
-	llamaCPath := flag.String("llama-c-path", filepath.Join(utils.GetProjectRoot(), "./llama-c-path"), "llama.cpp项目路径")
-	modelPath := flag.String("model-path", filepath.Join(utils.GetProjectRoot(), "model/Qwen3-1.7B-Q6_K.gguf"), "模型路径")
+	format := flag.String("format", "conventional", i18n.T(i18n.FlagFormat))
+	repoPath := flag.String("repo", ".", i18n.T(i18n.FlagRepo))
+	stagedOnly := flag.Bool("staged", true, i18n.T(i18n.FlagStaged))
+	autoCommit := flag.Bool("auto-commit", false, i18n.T(i18n.FlagAutoCommit))
+	enableDebug := flag.Bool("debug", false, i18n.T(i18n.FlagDebug))
+	onlyPrompt := flag.Bool("only-prompt", false, i18n.T(i18n.FlagOnlyPrompt))
+	llamaCPath := flag.String("llama-c-path", filepath.Join(utils.GetProjectRoot(), "./llama-c-path"), i18n.T(i18n.FlagLlamaCPath))
+	modelPath := flag.String("model-path", defaultModelPath(cfg), i18n.T(i18n.FlagModelPath))
+	backendName := flag.String("backend", ai.BackendLlamaCLI, i18n.T(i18n.FlagBackend, strings.Join(ai.BackendNames(), ", ")))
+	serverURL := flag.String("server-url", ai.DefaultServerURL, i18n.T(i18n.FlagServerURL))
+	serverEndpoint := flag.String("server-endpoint", ai.ServerEndpointCompletion, i18n.T(i18n.FlagServerEndpoint))
+	spawnServer := flag.Bool("spawn-server", false, i18n.T(i18n.FlagSpawnServer))
+	modelName := flag.String("model-name", "", i18n.T(i18n.FlagModelName))
+	chatTemplate := flag.String("chat-template", "", i18n.T(i18n.FlagChatTemplate, strings.Join(ai.ChatTemplateNames(), ", ")))
+	openAIBaseURL := flag.String("openai-base-url", ai.DefaultOpenAIBaseURL, i18n.T(i18n.FlagOpenAIBaseURL))
+	openAIAPIKeyEnv := flag.String("openai-api-key-env", ai.DefaultOpenAIAPIKeyEnv, i18n.T(i18n.FlagOpenAIAPIKeyEnv))
+	openAITimeout := flag.Duration("openai-timeout", time.Minute, i18n.T(i18n.FlagOpenAITimeout))
+	openAIKwargs := flag.Bool("openai-template-kwargs", true, i18n.T(i18n.FlagOpenAIKwargs))
+	jsonMode := flag.Bool("json-mode", true, i18n.T(i18n.FlagJSONMode))
+	ollamaURL := flag.String("ollama-url", ai.DefaultOllamaURL, i18n.T(i18n.FlagOllamaURL))
+	ollamaModel := flag.String("ollama-model", ai.DefaultOllamaModel, i18n.T(i18n.FlagOllamaModel))
+	ollamaEndpoint := flag.String("ollama-endpoint", ai.OllamaEndpointGenerate, i18n.T(i18n.FlagOllamaEndpoint))
+	replayDir := flag.String("replay-dir", filepath.Join(utils.GetProjectRoot(), "testdata/replay"), i18n.T(i18n.FlagReplayDir))
+	record := flag.Bool("record", false, i18n.T(i18n.FlagRecord))
+	constrain := flag.String("constrain", ai.ConstrainGrammar, i18n.T(i18n.FlagConstrain))
+	retries := flag.Int("retries", 2, i18n.T(i18n.FlagRetries))
+	candidates := flag.Int("candidates", 1, i18n.T(i18n.FlagCandidates))
+	mapReduce := flag.String("map-reduce", ai.MapReduceOff, i18n.T(i18n.FlagMapReduce))
+	stream := flag.Bool("stream", true, i18n.T(i18n.FlagStream))
+	temperature := flag.Float64("temperature", 0, i18n.T(i18n.FlagTemperature))
+	maxTokens := flag.Int("max-tokens", 2048, i18n.T(i18n.FlagMaxTokens))
+	topP := flag.Float64("top-p", 0.8, i18n.T(i18n.FlagTopP))
+	topK := flag.Int("top-k", 20, i18n.T(i18n.FlagTopK))
+	minP := flag.Float64("min-p", 0, i18n.T(i18n.FlagMinP))
+	seed := flag.Int("seed", -1, i18n.T(i18n.FlagSeed))
+	timeout := flag.Duration("timeout", 2*time.Minute, i18n.T(i18n.FlagTimeout))
+	threads := flag.Int("threads", 0, i18n.T(i18n.FlagThreads))
+	parallel := flag.Int("parallel", 0, i18n.T(i18n.FlagParallel, ai.DefaultParallel))
+	ctxSize := flag.Int("ctx-size", 0, i18n.T(i18n.FlagCtxSize))
+	locale := flag.String("locale", i18n.Locale(), i18n.T(i18n.FlagLocale, strings.Join(i18n.Locales(), ", ")))
+	lang := flag.String("lang", defaultLang, i18n.T(i18n.FlagLang, strings.Join(ai.Langs(), ", ")))
+	history := flag.Int("history", 50, i18n.T(i18n.FlagHistory))
+	noCache := flag.Bool("no-cache", false, i18n.T(i18n.FlagNoCache))
+	think := flag.Bool("think", false, i18n.T(i18n.FlagThink))
+	thinkTokens := flag.Int("think-tokens", 1024, i18n.T(i18n.FlagThinkTokens))
+	showThinking := flag.Bool("show-thinking", false, i18n.T(i18n.FlagShowThinking))
+	offlineHeuristic := flag.Bool("offline-heuristic", false, i18n.T(i18n.FlagOfflineHeuristic))
+	fallback := flag.Bool("fallback", true, i18n.T(i18n.FlagFallback))
+	validate := flag.Bool("validate", true, i18n.T(i18n.FlagValidate))
 	flag.Parse()
 
+	if !isSupportedLocale(*locale) {
+		fmt.Println(i18n.T(i18n.CLIUnsupportedLocale, *locale, strings.Join(i18n.Locales(), ", ")))
+		os.Exit(1)
+	}
+
 	// 从环境变量获取参数
 	formatEnv := os.Getenv("FORMAT")
 	if formatEnv != "" {
@@ -39,76 +126,319 @@ func main() {
 	if llamaCPathEnv != "" {
 		llamaCPath = &llamaCPathEnv
 	}
+	backendEnv := os.Getenv("BACKEND")
+	if backendEnv != "" {
+		backendName = &backendEnv
+	}
+	serverURLEnv := os.Getenv("SERVER_URL")
+	if serverURLEnv != "" {
+		serverURL = &serverURLEnv
+	}
+
+	openAIBaseURLEnv := os.Getenv("OPENAI_BASE_URL")
+	if openAIBaseURLEnv != "" {
+		openAIBaseURL = &openAIBaseURLEnv
+	}
+
+	ollamaURLEnv := os.Getenv("OLLAMA_HOST")
+	if ollamaURLEnv != "" {
+		ollamaURL = &ollamaURLEnv
+	}
+
+	langEnv := os.Getenv("COMMIT_LANG")
+	if langEnv != "" {
+		lang = &langEnv
+	}
+	if err := ai.ValidateLang(*lang); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// 创建Git客户端
 	gitClient := git.NewClient(*repoPath)
 
+	// 提示词模板放在仓库根目录的.aimmit/prompts中，不在仓库中时使用--repo指定的路径
+	repoRoot, err := gitClient.TopLevel(context.Background())
+	if err != nil {
+		repoRoot = *repoPath
+	}
+
 	// 创建AI客户端
 	aiClient := ai.NewClient(*enableDebug)
 	aiClient.SetLlamaCppPath(*llamaCPath)
 	aiClient.SetModel(*modelPath)
+	aiClient.SetBackendName(*backendName)
+	aiClient.SetServerURL(*serverURL)
+	aiClient.SetServerEndpoint(*serverEndpoint)
+	aiClient.SetSpawnServer(*spawnServer)
+	if *modelName != "" {
+		aiClient.SetModelName(*modelName)
+	}
+	aiClient.SetChatTemplate(*chatTemplate)
+	aiClient.SetOpenAIBaseURL(*openAIBaseURL)
+	aiClient.SetOpenAIAPIKey(os.Getenv(*openAIAPIKeyEnv))
+	aiClient.SetOpenAITimeout(*openAITimeout)
+	aiClient.SetOpenAITemplateKwargs(*openAIKwargs)
+	aiClient.SetJSONMode(*jsonMode)
+	aiClient.SetOllamaURL(*ollamaURL)
+	aiClient.SetOllamaModel(*ollamaModel)
+	aiClient.SetOllamaEndpoint(*ollamaEndpoint)
+	aiClient.SetReplayDir(*replayDir)
+	aiClient.SetRecord(*record)
+	aiClient.SetConstrain(*constrain)
+	aiClient.SetMaxRetries(*retries)
+	aiClient.SetMapReduce(*mapReduce)
+	aiClient.SetTemperature(*temperature)
+	aiClient.SetMaxTokens(*maxTokens)
+	aiClient.SetTopP(*topP)
+	aiClient.SetTopK(*topK)
+	aiClient.SetMinP(*minP)
+	aiClient.SetSeed(*seed)
+	aiClient.SetTimeout(*timeout)
+	aiClient.SetThreads(*threads)
+	aiClient.SetParallel(*parallel)
+	aiClient.SetContextSize(*ctxSize)
+	aiClient.SetLang(*lang)
+	aiClient.SetThink(*think)
+	aiClient.SetThinkTokens(*thinkTokens)
+	aiClient.SetShowThinking(*showThinking)
+	aiClient.SetHeuristic(*offlineHeuristic)
+	aiClient.SetFallback(*fallback)
+	aiClient.SetValidate(*validate)
+	// 仓库中有commitlint配置时，按配置中的规则生成和检查commit message
+	rules, rulesPath, err := lint.LoadRules(repoRoot, ai.CommitTypes)
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLICommitlintFailed, err))
+		rules = lint.DefaultRules(ai.CommitTypes)
+	} else if rulesPath != "" && *enableDebug {
+		fmt.Println(i18n.T(i18n.CLICommitlintLoaded, rulesPath))
+	}
+	aiClient.SetRules(rules)
+	aiClient.SetPromptDirs(ai.PromptDirs(repoRoot))
+	// 录制回放数据时需要真正调用后端，不使用缓存
+	if !*noCache && !*record {
+		if dir, err := cache.Dir(); err == nil {
+			aiClient.SetCache(cache.NewCache(dir))
+		}
+	}
+
+	// 等待模型输出时显示等待动画，并实时显示生成的内容
+	spinner := utils.NewSpinner(os.Stderr)
+	if *stream {
+		aiClient.SetStreamWriter(spinner)
+	}
 
 	// 创建Summarizer客户端
 	summarizerClient := summarizer.NewClient()
+	summarizerClient.SetLang(*lang)
 
 	if *enableDebug {
 		startTime := time.Now()
 		defer func() {
-			fmt.Printf("执行时间: %v\n", time.Since(startTime))
+			fmt.Println(i18n.T(i18n.CLIElapsed, time.Since(startTime)))
 		}()
 	}
 
+	// 收到Ctrl-C或SIGTERM时取消生成，并结束llama.cpp进程
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 从最近的提交中选出示例，读取失败时不使用示例
+	if *history > 0 {
+		commits, err := gitClient.RecentCommits(ctx, *history)
+		if err != nil {
+			if *enableDebug {
+				fmt.Println(i18n.T(i18n.CLIHistoryFailed, err))
+			}
+		} else {
+			aiClient.SetHistory(commits)
+		}
+	}
+
 	// 生成commit message模式
-	generateCommitMessage(gitClient, aiClient, summarizerClient, *format, *stagedOnly, *autoCommit, *onlyPrompt)
+	generateCommitMessage(ctx, gitClient, aiClient, summarizerClient, spinner, *format, *stagedOnly, *autoCommit, *onlyPrompt, *candidates)
 }
 
 // generateCommitMessage 生成commit message
-func generateCommitMessage(gitClient *git.Client, aiClient *ai.Client, summarizerClient *summarizer.Client, format string, stagedOnly, autoCommit, onlyPrompt bool) {
+func generateCommitMessage(ctx context.Context, gitClient *git.Client, aiClient *ai.Client, summarizerClient *summarizer.Client, spinner *utils.Spinner, format string, stagedOnly, autoCommit, onlyPrompt bool, candidates int) {
 	// 获取当前差异
-	diffInfo, err := gitClient.GetCurrentDiff(stagedOnly)
+	diffInfo, err := gitClient.GetCurrentDiff(ctx, stagedOnly)
 	if err != nil {
-		fmt.Printf("获取差异信息失败: %v\n", err)
+		exitIfCanceled(ctx)
+		fmt.Println(i18n.T(i18n.CLIGetDiffFailed, err))
 		os.Exit(1)
 	}
 
 	// 检查是否有差异
 	if diffInfo.RawDiff == "" && len(diffInfo.Files) == 0 {
-		fmt.Println("没有检测到任何更改")
+		fmt.Println(i18n.T(i18n.CLINoChanges))
 		os.Exit(0)
 	}
 
 	// 调用AI服务生成commit message
-	commitMsg, err := aiClient.GenerateCommitMessage(diffInfo, onlyPrompt)
+	if !onlyPrompt {
+		spinner.Start(i18n.T(i18n.CLIGenerating))
+	}
+	commitMsgs, err := aiClient.GenerateCandidates(ctx, diffInfo, candidates, onlyPrompt)
+	spinner.Stop()
 	if err != nil {
-		fmt.Printf("生成commit message失败: %v\n", err)
+		exitIfCanceled(ctx)
+		fmt.Println(i18n.T(i18n.CLIGenerateFailed, err))
 		os.Exit(1)
 	}
+	commitMsg := commitMsgs[0]
+	if commitMsg.Params != nil && commitMsg.Params.Fallback != "" {
+		fmt.Fprintln(os.Stderr, i18n.T(i18n.CLIHeuristicFallback, commitMsg.Params.Fallback))
+	}
+	if unfixed := lint.Unfixed(commitMsg.Violations); len(unfixed) > 0 {
+		messages := make([]string, len(unfixed))
+		for i, v := range unfixed {
+			messages[i] = v.Message
+		}
+		fmt.Fprintln(os.Stderr, i18n.T(i18n.CLILintViolations, strings.Join(messages, "; ")))
+	}
 
 	// 格式化并显示结果
-	output, err := summarizerClient.FormatCommitMessage(commitMsg, format)
+	var output string
+	if len(commitMsgs) > 1 {
+		output, err = summarizerClient.FormatCandidates(commitMsgs, format)
+	} else {
+		output, err = summarizerClient.FormatCommitMessage(commitMsg, format)
+	}
 	if err != nil {
-		fmt.Printf("格式化输出失败: %v\n", err)
+		fmt.Println(i18n.T(i18n.CLIFormatFailed, err))
 		os.Exit(1)
 	}
 
 	fmt.Println(output)
 
+	// 有多个候选时，提交前让用户选择
+	if autoCommit && len(commitMsgs) > 1 {
+		commitMsg = selectCandidate(commitMsgs)
+	}
+
 	// 如果启用了自动提交，执行git commit
 	if autoCommit {
 		// 获取约定式提交格式的commit message
 		conventionalMsg, err := summarizerClient.FormatCommitMessage(commitMsg, "conventional")
 		if err != nil {
-			fmt.Printf("格式化commit message失败: %v\n", err)
+			fmt.Println(i18n.T(i18n.CLIFormatCommitFailed, err))
 			os.Exit(1)
 		}
 
 		// 执行git commit
 		commitCmd := exec.Command("git", "-C", gitClient.RepoPath, "commit", "-m", conventionalMsg)
 		if err := commitCmd.Run(); err != nil {
-			fmt.Printf("执行git commit失败: %v\n", err)
+			fmt.Println(i18n.T(i18n.CLICommitFailed, err))
 			os.Exit(1)
 		}
 
-		fmt.Println("\n✅ 已成功提交更改")
+		fmt.Println("\n" + i18n.T(i18n.CLICommitted))
+	}
+}
+
+// runCacheCommand 执行cache子命令，目前只支持clear
+func runCacheCommand(args []string) {
+	// --locale已经由initLocale处理，这里只需要允许它出现在子命令前后
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	fs.String("locale", i18n.Locale(), i18n.T(i18n.FlagLocale, strings.Join(i18n.Locales(), ", ")))
+	args = parseInterspersed(fs, args)
+	if len(args) != 1 || args[0] != "clear" {
+		fmt.Println(i18n.T(i18n.CLICacheUsage))
+		os.Exit(2)
+	}
+
+	dir, err := cache.Dir()
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLICacheFailed, err))
+		os.Exit(1)
+	}
+	count, err := cache.NewCache(dir).Clear()
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLICacheFailed, err))
+		os.Exit(1)
+	}
+	fmt.Println(i18n.T(i18n.CLICacheCleared, count, dir))
+}
+
+// parseInterspersed 解析子命令的参数，允许参数出现在位置参数之间，返回位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	positional := []string{}
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// exitIfCanceled 在用户中断时输出提示并以130退出（与shell中Ctrl-C的退出码一致）
+func exitIfCanceled(ctx context.Context) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Fprintln(os.Stderr, "\n"+i18n.T(i18n.CLICanceled))
+		os.Exit(130)
+	}
+}
+
+// selectCandidate 从标准输入读取用户选择的候选编号，直接回车时使用第一个
+func selectCandidate(commitMsgs []*ai.CommitMessage) *ai.CommitMessage {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("\n" + i18n.T(i18n.CLISelectCandidate, len(commitMsgs)))
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err != nil && err != io.EOF {
+				fmt.Println(i18n.T(i18n.CLIReadInputFailed, err))
+				os.Exit(1)
+			}
+			return commitMsgs[0]
+		}
+
+		index, convErr := strconv.Atoi(line)
+		if convErr == nil && index >= 1 && index <= len(commitMsgs) {
+			return commitMsgs[index-1]
+		}
+		if err != nil {
+			fmt.Println("\n" + i18n.T(i18n.CLIInvalidChoice, line))
+			os.Exit(1)
+		}
+		fmt.Println(i18n.T(i18n.CLIInvalidChoice, line))
+	}
+}
+
+// initLocale 按环境变量和--locale参数设置界面语言，需要在定义命令行参数之前调用
+func initLocale() {
+	i18n.SetLocale(i18n.DetectLocale())
+
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "locale" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		i18n.SetLocale(value)
+	}
+}
+
+// isSupportedLocale 判断--locale的值是否是支持的界面语言
+func isSupportedLocale(locale string) bool {
+	for _, l := range i18n.Locales() {
+		if locale == l {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update 为true时用假的llama-cli重新录制testdata/replay中的回放数据：
+//
+//	go test ./cmd/aimmit -run TestReplay -update
+var update = flag.Bool("update", false, "用假的llama-cli重新录制testdata/replay中的回放数据")
+
+func TestMain(m *testing.M) {
+	// 测试在子进程中以aimmit的身份运行
+	if os.Getenv("AIMMIT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// replayCase 是一次完整的生成流程：仓库中暂存的文件、命令行参数和录制时模型的回复
+type replayCase struct {
+	name     string
+	files    map[string]string // 暂存的文件
+	args     []string          // 额外的命令行参数
+	response string            // 录制时假的llama-cli输出的回复
+	want     map[string]string // 每种输出格式的期望输出
+	wantJSON []string          // JSON格式的输出中应该包含的内容
+}
+
+var replayCases = []replayCase{
+	{
+		name: "zh",
+		files: map[string]string{
+			"internal/cache/cache.go": "package cache\n\n// Clear 删除所有缓存\nfunc Clear() error {\n\treturn nil\n}\n",
+		},
+		response: `{"type": "feat", "scope": "cache", "subject": "添加清除缓存的函数", "body": "新增Clear函数，用于删除所有缓存。", "breaking_changes": false}`,
+		want: map[string]string{
+			"conventional": "feat(cache): 添加清除缓存的函数\n\n新增Clear函数，用于删除所有缓存。\n",
+			"text":         "feat: 添加清除缓存的函数\n范围: cache\n\n新增Clear函数，用于删除所有缓存。\n\n",
+		},
+		wantJSON: []string{`"scope": "cache"`, `"backend": "replay"`, `"seed": 1`},
+	},
+	{
+		name: "en-fenced",
+		files: map[string]string{
+			"api/handler.go": "package api\n\n// Handle 处理请求，不再接受旧的参数格式\nfunc Handle(req Request) error {\n\treturn nil\n}\n",
+		},
+		args: []string{"--lang", "en", "--constrain", "none"},
+		// 不使用约束解码时模型可能把JSON放在代码块中，并在前后附加说明
+		response: "Here is the commit message:\n```json\n{\"type\": \"refactor\", \"scope\": \"api\", \"subject\": \"drop the legacy request format\", \"body\": \"Handle only accepts the new Request type.\", \"breaking_changes\": true}\n```\n",
+		want: map[string]string{
+			"conventional": "refactor(api)!: drop the legacy request format\n\nHandle only accepts the new Request type.\n\nBREAKING CHANGE: this commit contains breaking changes\n",
+		},
+		wantJSON: []string{`"breaking_changes": true`},
+	},
+	{
+		name: "bilingual-ja",
+		files: map[string]string{
+			"cli/flags.go": "package cli\n\n// Verbose 输出详细日志\nvar Verbose bool\n",
+		},
+		args:     []string{"--lang", "bilingual:ja"},
+		response: `{"type": "feat", "scope": "cli", "subject": "add a verbose flag", "body": "詳細なログを出力するVerboseフラグを追加。", "breaking_changes": true}`,
+		// 固定文字使用正文的语言
+		want: map[string]string{
+			"conventional": "feat(cli)!: add a verbose flag\n\n詳細なログを出力するVerboseフラグを追加。\n\nBREAKING CHANGE: このコミットには破壊的変更が含まれます\n",
+			"text":         "feat: add a verbose flag\n範囲: cli\n\n詳細なログを出力するVerboseフラグを追加。\n\n⚠️ 破壊的変更を含みます\n\n",
+		},
+	},
+	{
+		name: "lint-fix",
+		files: map[string]string{
+			"README.md": "# demo\n\nUsage: run `demo --help`.\n",
+		},
+		// 类型使用别名、主题以大写字母开头并带句号，检查时自动修正
+		response: `{"type": "Documentation", "scope": "", "subject": "Add usage to README.", "body": "", "breaking_changes": false}`,
+		want: map[string]string{
+			"conventional": "docs: add usage to README\n",
+		},
+		wantJSON: []string{`"rule": "type-enum"`, `"rule": "subject-full-stop"`, `"rule": "subject-case"`, `"fixed": true`},
+	},
+}
+
+// projectRoot 返回仓库的根目录
+func projectRoot(t *testing.T) string {
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// newStagedRepo 创建一个暂存了files的仓库
+func newStagedRepo(t *testing.T, files map[string]string) string {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	git("init", "-q")
+	for name, content := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", "-A")
+	return repo
+}
+
+// runAimmit 在子进程中运行aimmit，返回标准输出
+func runAimmit(t *testing.T, env []string, args ...string) string {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"AIMMIT_TEST_MAIN=1",
+		// 不使用用户的缓存和配置，也不受界面语言的环境变量影响
+		"XDG_CACHE_HOME="+t.TempDir(),
+		"XDG_CONFIG_HOME="+t.TempDir(),
+		"LC_ALL=",
+		"LC_MESSAGES=",
+		"LANG=",
+		"FORMAT=",
+		"REPO=",
+		"BACKEND=",
+		"COMMIT_LANG=",
+	)
+	cmd.Env = append(cmd.Env, env...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("aimmit %s: %v\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), err, output, stderr.String())
+	}
+	return string(output)
+}
+
+// TestReplay 通过回放后端运行完整的流程：读取diff、构建提示词、解析和检查回复、按各种格式输出
+func TestReplay(t *testing.T) {
+	root := projectRoot(t)
+	replayDir := filepath.Join(root, "testdata", "replay")
+	if *update {
+		files, _ := filepath.Glob(filepath.Join(replayDir, "*.json"))
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}
+
+	for _, tc := range replayCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newStagedRepo(t, tc.files)
+			args := append([]string{"--repo", repo, "--locale", "zh", "--stream=false", "--seed", "1", "--replay-dir", replayDir}, tc.args...)
+
+			if *update {
+				record := append([]string{"--backend", "llama-cli", "--record",
+					"--llama-c-path", filepath.Join(root, "testdata", "fake-llama"),
+					"--model-path", filepath.Join(t.TempDir(), "model.gguf")}, args...)
+				runAimmit(t, []string{"FAKE_LLAMA_RESPONSE=" + tc.response}, record...)
+			}
+
+			replay := append([]string{"--backend", "replay", "--no-cache"}, args...)
+			for format, want := range tc.want {
+				got := runAimmit(t, nil, append(replay, "--format", format)...)
+				if got != want {
+					t.Errorf("--format %s:\ngot:\n%s\nwant:\n%s", format, got, want)
+				}
+			}
+			got := runAimmit(t, nil, append(replay, "--format", "json")...)
+			for _, want := range tc.wantJSON {
+				if !strings.Contains(got, want) {
+					t.Errorf("--format json: output does not contain %s:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestConfigLang 使用配置文件中的lang作为--lang的默认值，不支持的语言回退到默认语言
+func TestConfigLang(t *testing.T) {
+	root := projectRoot(t)
+	cases := map[string]replayCase{}
+	for _, tc := range replayCases {
+		cases[tc.name] = tc
+	}
+
+	for _, tt := range []struct {
+		lang string
+		tc   replayCase
+	}{
+		{lang: "bilingual:ja", tc: cases["bilingual-ja"]},
+		{lang: "xx", tc: cases["zh"]},
+	} {
+		t.Run(tt.lang, func(t *testing.T) {
+			configHome := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(configHome, "aimmit"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(configHome, "aimmit", "config.json"), []byte(`{"lang": "`+tt.lang+`"}`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			repo := newStagedRepo(t, tt.tc.files)
+			got := runAimmit(t, []string{"XDG_CONFIG_HOME=" + configHome},
+				"--backend", "replay", "--no-cache", "--repo", repo, "--locale", "zh", "--stream=false", "--seed", "1",
+				"--replay-dir", filepath.Join(root, "testdata", "replay"), "--format", "conventional")
+			if want := tt.tc.want["conventional"]; !strings.HasSuffix(got, want) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if tt.lang == "xx" && !strings.Contains(got, "xx") {
+				t.Errorf("unsupported lang is not reported:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rust17/AImmit/internal/config"
+	"github.com/rust17/AImmit/internal/gguf"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/models"
+	"github.com/rust17/AImmit/internal/utils"
+)
+
+// defaultModelFile 是没有在配置中选择模型时使用的模型文件
+const defaultModelFile = "model/Qwen3-1.7B-Q6_K.gguf"
+
+// defaultModelPath 返回默认的模型文件路径：配置中选择的模型优先，否则使用项目model目录中的默认模型
+func defaultModelPath(cfg *config.Config) string {
+	if cfg.Model != "" {
+		return cfg.Model
+	}
+	return filepath.Join(utils.GetProjectRoot(), defaultModelFile)
+}
+
+// modelDirs 返回查找GGUF模型的目录：项目的model目录、配置中的目录和--dir指定的目录
+func modelDirs(cfg *config.Config, extra string) []string {
+	dirs := []string{filepath.Join(utils.GetProjectRoot(), "model")}
+	dirs = append(dirs, cfg.ModelDirs...)
+	if extra != "" {
+		dirs = append(dirs, extra)
+	}
+	return dirs
+}
+
+// runModelCommand 执行model子命令：list列出模型，verify校验模型文件，use选择默认模型
+func runModelCommand(args []string) {
+	// --locale已经由initLocale处理，这里只需要允许它出现在子命令前后
+	fs := flag.NewFlagSet("model", flag.ExitOnError)
+	fs.String("locale", i18n.Locale(), i18n.T(i18n.FlagLocale, strings.Join(i18n.Locales(), ", ")))
+	dir := fs.String("dir", "", i18n.T(i18n.FlagModelDir))
+	args = parseInterspersed(fs, args)
+	if len(args) == 0 {
+		fmt.Println(i18n.T(i18n.CLIModelUsage))
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLIModelFailed, err))
+		os.Exit(1)
+	}
+	dirs := modelDirs(cfg, *dir)
+	found, err := models.Scan(dirs)
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLIModelFailed, err))
+		os.Exit(1)
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		listModels(found, dirs, defaultModelPath(cfg))
+	case args[0] == "verify":
+		verifyModels(found, dirs, args[1:])
+	case args[0] == "use" && len(args) == 2:
+		useModel(cfg, found, args[1])
+	default:
+		fmt.Println(i18n.T(i18n.CLIModelUsage))
+		os.Exit(2)
+	}
+}
+
+// listModels 以表格形式列出模型及其GGUF元数据，默认模型前标有*
+func listModels(found []models.Model, dirs []string, defaultPath string) {
+	if len(found) == 0 {
+		fmt.Println(i18n.T(i18n.CLIModelNone, strings.Join(dirs, ", ")))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T(i18n.CLIModelHeader))
+	invalid := []models.Model{}
+	for _, model := range found {
+		mark := " "
+		if model.Path == defaultPath {
+			mark = "*"
+		}
+		arch, size, quant, ctx := "-", "-", "-", "-"
+		if model.Metadata != nil {
+			arch = orDash(model.Metadata.Architecture())
+			size = orDash(model.Metadata.SizeLabel())
+			quant = orDash(model.Metadata.FileType())
+			if n := model.Metadata.ContextLength(); n > 0 {
+				ctx = fmt.Sprint(n)
+			}
+		} else {
+			invalid = append(invalid, model)
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\t%s\n", mark, model.Name, formatBytes(model.Size), arch, size, quant, ctx, model.Dir)
+	}
+	w.Flush()
+
+	for _, model := range invalid {
+		fmt.Println(i18n.T(i18n.CLIModelInvalid, model.Name, model.Err))
+	}
+}
+
+// verifyModels 用模型目录中的SHA256SUMS校验模型文件，没有指定模型时校验所有模型，
+// 并报告各目录的清单中记录但不存在的文件，有文件未通过时以1退出
+func verifyModels(found []models.Model, dirs, names []string) {
+	selected := found
+	if len(names) > 0 {
+		dirs = nil
+		selected = []models.Model{}
+		for _, name := range names {
+			model := models.Find(found, name)
+			if model == nil {
+				fmt.Println(i18n.T(i18n.CLIModelNotFound, name))
+				os.Exit(1)
+			}
+			selected = append(selected, *model)
+		}
+	}
+
+	results, err := models.Verify(selected, dirs)
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLIModelFailed, err))
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch result.Status {
+		case models.StatusOK:
+			fmt.Println(i18n.T(i18n.CLIModelVerifyOK, result.Name))
+		case models.StatusMismatch:
+			failed++
+			fmt.Println(i18n.T(i18n.CLIModelVerifyMismatch, result.Name, result.Expected, result.Actual))
+		case models.StatusUnlisted:
+			failed++
+			fmt.Println(i18n.T(i18n.CLIModelVerifyUnlisted, result.Name, filepath.Join(result.Dir, models.ManifestName)))
+		case models.StatusMissing:
+			failed++
+			fmt.Println(i18n.T(i18n.CLIModelVerifyMissing, result.Name, filepath.Join(result.Dir, models.ManifestName)))
+		}
+	}
+	fmt.Println(i18n.T(i18n.CLIModelVerifySummary, len(results), failed))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// useModel 把模型设为默认模型并保存到配置文件，name可以是模型目录中的文件名或GGUF文件的路径
+func useModel(cfg *config.Config, found []models.Model, name string) {
+	path := ""
+	if model := models.Find(found, name); model != nil {
+		path = model.Path
+	} else if info, err := os.Stat(name); err == nil && !info.IsDir() {
+		path, _ = filepath.Abs(name)
+	} else {
+		fmt.Println(i18n.T(i18n.CLIModelNotFound, name))
+		os.Exit(1)
+	}
+	if _, err := gguf.ReadFile(path); err != nil {
+		fmt.Println(i18n.T(i18n.CLIModelInvalid, name, err))
+		os.Exit(1)
+	}
+
+	cfg.Model = path
+	configPath, err := cfg.Save()
+	if err != nil {
+		fmt.Println(i18n.T(i18n.CLIModelFailed, err))
+		os.Exit(1)
+	}
+	fmt.Println(i18n.T(i18n.CLIModelUsed, path, configPath))
+}
+
+// formatBytes 以易读的单位显示文件大小
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// orDash 把空字符串显示为-
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,52 +1,184 @@
 package ai
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"os"
-	"os/exec"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/rust17/AImmit/internal/cache"
+	"github.com/rust17/AImmit/internal/gguf"
 	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
 )
 
 // Client 是AI服务的客户端
 type Client struct {
-	debug        bool    // 是否开启debug模式
-	modelPath    string  // llama.cpp模型文件路径
-	modelName    string  // 模型名称
-	llamaCppPath string  // llama.cpp可执行文件路径
-	temperature  float64 // 生成温度
-	maxTokens    int     // 最大生成的token数
-	topP         float64 // top-p
-	topK         int     // top-k
-	minP         float64 // min-p
+	debug            bool               // 是否开启debug模式
+	modelPath        string             // llama.cpp模型文件路径
+	modelName        string             // 模型名称
+	modelNameSet     bool               // 是否显式设置了模型名称
+	templateName     string             // 对话模板名称，为空时根据模型名称自动识别
+	metadata         *gguf.Metadata     // 模型文件的GGUF元数据
+	metadataLoaded   bool               // 是否已经尝试读取GGUF元数据
+	llamaCppPath     string             // llama.cpp可执行文件路径
+	temperature      float64            // 生成温度
+	maxTokens        int                // 最大生成的token数
+	topP             float64            // top-p
+	topK             int                // top-k
+	minP             float64            // min-p
+	seed             int                // 随机种子，小于0表示随机
+	randomSeed       int                // seed小于0时实际使用的随机种子，记录下来以便复现
+	threads          int                // 推理线程数，为0时使用推理引擎的默认值
+	parallel         int                // 并发请求数，为0时由后端决定
+	ctxSize          int                // 上下文长度，为0时根据模型自动确定
+	timeout          time.Duration      // 单次生成的超时时间
+	backendName      string             // 推理后端名称
+	serverURL        string             // llama-server地址
+	serverEndpoint   string             // llama-server接口（completion或chat）
+	spawnServer      bool               // llama-server未运行时是否自动启动
+	openAIBaseURL    string             // OpenAI兼容接口地址
+	openAIAPIKey     string             // OpenAI兼容接口的API Key
+	openAITimeout    time.Duration      // OpenAI兼容接口的请求超时
+	openAIKwargs     bool               // OpenAI兼容接口是否发送chat_template_kwargs
+	jsonMode         bool               // 是否要求后端以JSON对象格式返回
+	ollamaURL        string             // Ollama地址
+	ollamaModel      string             // Ollama模型标签
+	ollamaEndpoint   string             // Ollama接口（generate或chat）
+	replayDir        string             // 回放数据目录
+	record           bool               // 是否把回复录制为回放数据
+	constrain        string             // 约束解码模式（none, json-schema, grammar）
+	maxRetries       int                // 回复无法解析时的最大重试次数
+	mapReduce        string             // 分段总结模式（off, auto, always）
+	lang             string             // commit message使用的语言
+	promptDirs       []string           // 查找提示词模板的目录
+	history          []git.Commit       // 仓库最近的提交，用于选出提示词中的示例
+	cache            *cache.Cache       // 生成结果的缓存，为空时不使用缓存
+	think            bool               // 是否允许模型在回答前先推理
+	thinkTokens      int                // 思考模式下为推理内容额外预留的token数
+	showThinking     bool               // 是否显示模型的推理内容
+	heuristic        bool               // 是否不调用模型，直接用启发式规则生成
+	rules            *lint.Rules        // 生成结果需要满足的规则，为空时不检查
+	validate         bool               // 是否按规则检查生成结果
+	fallback         bool               // 模型生成失败时是否改用启发式规则生成
+	commitTmpl       *template.Template // 自定义的提示词模板
+	commitTmplLoaded bool               // 是否已经尝试加载提示词模板
+	streamWriter     io.Writer          // 实时输出生成内容，为空时不输出
+	backend          Backend            // 推理后端，为空时按backendName创建
 }
 
 // NewClient 创建一个新的AI客户端
 // 参数是模型文件路径，如果为空则尝试使用默认路径
 func NewClient(debug bool) *Client {
 	return &Client{
-		debug:     debug,
-		modelName: "Qwen3", // 默认使用Qwen3模型
-		maxTokens: 2048,
-		topP:      0.8,
-		topK:      20,
-		minP:      0,
+		debug:         debug,
+		modelName:     "Qwen3", // 默认使用Qwen3模型
+		maxTokens:     2048,
+		topP:          0.8,
+		topK:          20,
+		minP:          0,
+		seed:          -1,
+		randomSeed:    rand.Intn(math.MaxInt32),
+		timeout:       2 * time.Minute,
+		backendName:   BackendLlamaCLI,
+		openAITimeout: time.Minute,
+		openAIKwargs:  true,
+		jsonMode:      true,
+		constrain:     ConstrainGrammar,
+		maxRetries:    2,
+		mapReduce:     MapReduceOff,
+		lang:          DefaultLang,
+		thinkTokens:   defaultThinkTokens,
+		rules:         lint.DefaultRules(CommitTypes),
+		validate:      true,
+		fallback:      true,
 	}
 }
 
 // SetModel 设置要使用的模型路径
 func (c *Client) SetModel(modelPath string) {
 	c.modelPath = modelPath
+	c.metadata = nil
+	c.metadataLoaded = false
 }
 
-// SetModelName 设置模型名称
+// SetModelName 设置模型名称，未指定对话模板时会根据模型名称识别模型家族
 func (c *Client) SetModelName(modelName string) {
 	c.modelName = modelName
+	c.modelNameSet = true
+}
+
+// SetChatTemplate 设置对话模板名称，为空时根据模型名称或模型文件名自动识别
+func (c *Client) SetChatTemplate(name string) {
+	c.templateName = name
+}
+
+// chatTemplate 返回当前使用的对话模板：
+// 显式指定的模板 > 根据显式设置的模型名称识别 > 根据GGUF元数据识别 > 根据模型文件名识别 > 默认模板
+func (c *Client) chatTemplate() (*ChatTemplate, error) {
+	if c.templateName != "" {
+		return GetChatTemplate(c.templateName)
+	}
+	name := ""
+	if c.modelNameSet {
+		name = DetectChatTemplate(c.modelName)
+	}
+	if metadata := c.modelMetadata(); name == "" && metadata != nil {
+		name = DetectChatTemplateFromGGUF(metadata.Architecture(), metadata.ChatTemplate())
+	}
+	if name == "" && c.modelPath != "" {
+		name = DetectChatTemplate(c.modelPath)
+	}
+	if name == "" {
+		name = DetectChatTemplate(c.modelName)
+	}
+	if name == "" {
+		name = DefaultChatTemplate
+	}
+	return GetChatTemplate(name)
+}
+
+// modelMetadata 读取并缓存模型文件的GGUF元数据，模型文件不存在或不是GGUF时返回nil
+func (c *Client) modelMetadata() *gguf.Metadata {
+	if c.metadataLoaded {
+		return c.metadata
+	}
+	c.metadataLoaded = true
+	if c.modelPath == "" {
+		return nil
+	}
+	metadata, err := gguf.ReadFile(c.modelPath)
+	if err != nil {
+		if c.debug {
+			fmt.Println(i18n.T(i18n.AIDebugGGUFFailed, err))
+		}
+		return nil
+	}
+	c.metadata = metadata
+	return metadata
+}
+
+// contextSize 返回传给推理引擎的上下文长度：显式设置的值，或者模型的上下文长度（最多maxContextSize）
+func (c *Client) contextSize() int {
+	if c.ctxSize > 0 {
+		return c.ctxSize
+	}
+	metadata := c.modelMetadata()
+	if metadata == nil || metadata.ContextLength() <= 0 {
+		return 0
+	}
+	if metadata.ContextLength() > maxContextSize {
+		return maxContextSize
+	}
+	return metadata.ContextLength()
 }
 
 // SetLlamaCppPath 设置llama.cpp可执行文件路径
@@ -54,247 +186,425 @@ func (c *Client) SetLlamaCppPath(path string) {
 	c.llamaCppPath = path
 }
 
+// SetBackendName 设置推理后端名称
+func (c *Client) SetBackendName(name string) {
+	c.backendName = name
+	c.backend = nil
+}
+
+// SetServerURL 设置llama-server地址
+func (c *Client) SetServerURL(serverURL string) {
+	c.serverURL = serverURL
+}
+
+// SetServerEndpoint 设置llama-server使用的接口（completion或chat）
+func (c *Client) SetServerEndpoint(endpoint string) {
+	c.serverEndpoint = endpoint
+}
+
+// SetSpawnServer 设置llama-server未运行时是否自动启动
+func (c *Client) SetSpawnServer(spawn bool) {
+	c.spawnServer = spawn
+}
+
+// SetOpenAIBaseURL 设置OpenAI兼容接口地址
+func (c *Client) SetOpenAIBaseURL(baseURL string) {
+	c.openAIBaseURL = baseURL
+}
+
+// SetOpenAIAPIKey 设置OpenAI兼容接口的API Key
+func (c *Client) SetOpenAIAPIKey(apiKey string) {
+	c.openAIAPIKey = apiKey
+}
+
+// SetOpenAITimeout 设置OpenAI兼容接口的请求超时
+func (c *Client) SetOpenAITimeout(timeout time.Duration) {
+	c.openAITimeout = timeout
+}
+
+// SetOpenAITemplateKwargs 设置OpenAI兼容接口是否通过chat_template_kwargs控制思考模式
+func (c *Client) SetOpenAITemplateKwargs(enabled bool) {
+	c.openAIKwargs = enabled
+}
+
+// SetJSONMode 设置是否要求后端以JSON对象格式返回
+func (c *Client) SetJSONMode(enabled bool) {
+	c.jsonMode = enabled
+}
+
+// SetOllamaURL 设置Ollama地址
+func (c *Client) SetOllamaURL(url string) {
+	c.ollamaURL = url
+}
+
+// SetOllamaModel 设置Ollama模型标签，例如 qwen3:1.7b
+func (c *Client) SetOllamaModel(model string) {
+	c.ollamaModel = model
+}
+
+// SetOllamaEndpoint 设置Ollama接口（generate或chat）
+func (c *Client) SetOllamaEndpoint(endpoint string) {
+	c.ollamaEndpoint = endpoint
+}
+
+// SetReplayDir 设置回放数据目录
+func (c *Client) SetReplayDir(dir string) {
+	c.replayDir = dir
+}
+
+// SetRecord 设置是否把后端的回复录制到回放数据目录
+func (c *Client) SetRecord(record bool) {
+	c.record = record
+}
+
+// SetConstrain 设置约束解码模式（none, json-schema, grammar）
+func (c *Client) SetConstrain(mode string) {
+	c.constrain = mode
+}
+
+// SetMaxRetries 设置回复无法解析时的最大重试次数
+func (c *Client) SetMaxRetries(retries int) {
+	c.maxRetries = retries
+}
+
+// SetMapReduce 设置分段总结模式（off, auto, always）
+func (c *Client) SetMapReduce(mode string) {
+	c.mapReduce = mode
+}
+
+// SetStreamWriter 设置实时输出生成内容的位置，为nil时不输出
+func (c *Client) SetStreamWriter(w io.Writer) {
+	c.streamWriter = w
+}
+
+// SetLang 设置commit message使用的语言
+func (c *Client) SetLang(lang string) {
+	c.lang = lang
+	c.commitTmpl = nil
+	c.commitTmplLoaded = false
+}
+
+// SetBackend 直接设置推理后端实例
+func (c *Client) SetBackend(backend Backend) {
+	c.backend = backend
+}
+
 // SetTemperature 设置生成温度
 func (c *Client) SetTemperature(temp float64) {
 	c.temperature = temp
 }
 
+// SetSeed 设置随机种子，小于0表示随机
+func (c *Client) SetSeed(seed int) {
+	c.seed = seed
+}
+
 // SetMaxTokens 设置最大生成的token数
 func (c *Client) SetMaxTokens(tokens int) {
 	c.maxTokens = tokens
 }
 
+// SetTopP 设置top-p
+func (c *Client) SetTopP(topP float64) {
+	c.topP = topP
+}
+
+// SetTopK 设置top-k
+func (c *Client) SetTopK(topK int) {
+	c.topK = topK
+}
+
+// SetMinP 设置min-p
+func (c *Client) SetMinP(minP float64) {
+	c.minP = minP
+}
+
+// SetThreads 设置推理线程数，为0时使用推理引擎的默认值
+func (c *Client) SetThreads(threads int) {
+	c.threads = threads
+}
+
+// SetParallel 设置同时发给后端的请求数，也是自动启动llama-server时的slot数，为0时由后端决定
+func (c *Client) SetParallel(parallel int) {
+	c.parallel = parallel
+}
+
+// SetContextSize 设置上下文长度，为0时根据模型自动确定
+func (c *Client) SetContextSize(size int) {
+	c.ctxSize = size
+}
+
+// SetTimeout 设置单次生成的超时时间
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
 // CommitMessage 表示生成的提交信息
 type CommitMessage struct {
-	Subject         string `json:"subject"`          // 提交的主题行（简短描述）
-	Body            string `json:"body"`             // 提交的详细描述
-	Type            string `json:"type"`             // 提交类型（feat, fix, docs等）
-	Scope           string `json:"scope"`            // 影响范围
-	BreakingChanges bool   `json:"breaking_changes"` // 是否包含破坏性变更
-	RawDiff         string `json:"-"`                // 原始diff内容（不包含在JSON输出中）
-}
-
-// callLlamaCpp 调用llama.cpp可执行文件生成回复
-func (c *Client) callLlamaCpp(prompt string, onlyPrompt bool) (string, error) {
-	// 终止标记（可以自定义）
-	stopMarker := "<|end_of_text|>"
-	// 添加系统提示到用户提示之前
-	fullPrompt := fmt.Sprintf("<|im_start|>system\n你是一个专业的代码提交分析助手，擅长总结Git提交历史和生成规范的commit message。可以拼接技术术语英文，不过请尽可能用中文回答。请以字符%s结束/no_think<|im_end|>\n<|im_start|>user\n%s<|im_end|>\n<|im_start|>assistant\n", stopMarker, prompt)
-	// 超时时间
-	timeout := 2 * time.Minute
+	Subject         string            `json:"subject"`          // 提交的主题行（简短描述）
+	Body            string            `json:"body"`             // 提交的详细描述
+	Type            string            `json:"type"`             // 提交类型（feat, fix, docs等）
+	Scope           string            `json:"scope"`            // 影响范围
+	BreakingChanges bool              `json:"breaking_changes"` // 是否包含破坏性变更
+	RawDiff         string            `json:"-"`                // 原始diff内容（不包含在JSON输出中）
+	Params          *GenerationParams `json:"-"`                // 生成时实际使用的参数
+	Violations      []lint.Violation  `json:"-"`                // 不符合规范的地方，包括已经自动修正的
+}
+
+// maxContextSize 是自动设置上下文长度时的上限，避免为超长上下文的模型分配过多内存
+const maxContextSize = 8192
 
+// generate 通过推理后端生成回复，variant大于0时使用不同的温度和种子生成变体
+func (c *Client) generate(ctx context.Context, prompt string, variant int, onlyPrompt bool) (string, error) {
+	req, err := c.newRequest(prompt, variant)
+	if err != nil {
+		return "", err
+	}
+	// 只实时输出第一个候选，多个候选并发生成时输出会交错
+	if variant == 0 && c.streamWriter != nil && !onlyPrompt {
+		w := c.streamWriter
+		req.Stream = func(text string) {
+			io.WriteString(w, text)
+		}
+		// 不显示推理内容时，从实时输出中去掉<think>块
+		if !c.showThinking {
+			filter := newThinkFilter(req.Stream)
+			req.Stream = filter.Write
+			defer filter.Flush()
+		}
+	}
+	return c.send(ctx, req, variant == 0, onlyPrompt)
+}
+
+// newRequest 构建生成CommitMessage的请求，按约束解码模式附加语法或JSON Schema
+func (c *Client) newRequest(prompt string, variant int) (*Request, error) {
+	req := &Request{
+		System:  c.prompts().system,
+		Prompt:  prompt,
+		Options: c.requestOptions(variant),
+	}
+	switch c.constrain {
+	case ConstrainGrammar:
+		// 不支持GBNF语法的后端会退回到JSON Schema
+		req.Options.Grammar = CommitMessageGrammar(allowedTypes(c.rules), allowedScopes(c.rules))
+		if c.think {
+			req.Options.Grammar = withThinking(req.Options.Grammar)
+		}
+		req.Options.JSONSchema = CommitMessageSchema(allowedTypes(c.rules), allowedScopes(c.rules))
+	case ConstrainJSONSchema:
+		req.Options.JSONSchema = CommitMessageSchema(allowedTypes(c.rules), allowedScopes(c.rules))
+	case ConstrainNone, "":
+	default:
+		return nil, i18n.New(i18n.AIUnsupportedConstrain, c.constrain)
+	}
+	return req, nil
+}
+
+// send 把请求发送给推理后端，showPrompt为true时在debug模式下输出提示词
+func (c *Client) send(ctx context.Context, req *Request, showPrompt, onlyPrompt bool) (string, error) {
+	backend, err := c.getBackend()
+	if err != nil {
+		return "", err
+	}
 	// 如果只是打印提示信息，则输出并退出
-	if onlyPrompt || c.debug {
-		fmt.Println(fullPrompt)
+	if onlyPrompt || (c.debug && showPrompt) {
+		if renderer, ok := backend.(PromptRenderer); ok {
+			fmt.Println(renderer.RenderPrompt(req))
+		} else {
+			fmt.Println(renderMessages(req))
+		}
 		if onlyPrompt {
 			os.Exit(1)
 		}
 	}
 
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
-	// 构建llama.cpp命令行参数
-	cmd := exec.CommandContext(
-		ctx,
-		c.llamaCppPath+"/llama-cli",
-		"-m", c.modelPath,
-		"-p", fullPrompt,
-		"--no-display-prompt",
-		"--n-predict", fmt.Sprintf("%d", c.maxTokens),
-		// Qwen3-1.7B-Q6_K.gguf 模型最佳参数
-		"--min-p", fmt.Sprintf("%.2f", c.minP),
-		"--temp", fmt.Sprintf("%.2f", c.temperature),
-		"--top-p", fmt.Sprintf("%.2f", c.topP),
-		"--top-k", fmt.Sprintf("%d", c.topK),
-	)
-	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+c.llamaCppPath)
-
-	// 创建管道获取实时输出
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("创建输出管道失败: %w", err)
+	resp, err := backend.Generate(ctx, req)
+	if resp == nil {
+		return "", err
 	}
-
-	if c.debug {
-		// 创建管道获取实时错误输出
-		stderrPipe, err := cmd.StderrPipe()
-		if err != nil {
-			return "", fmt.Errorf("创建错误输出管道失败: %w", err)
-		}
-
-		// 启动goroutine来处理错误输出
-		go func() {
-			scanner := bufio.NewScanner(stderrPipe)
-			for scanner.Scan() {
-				fmt.Println(scanner.Text())
-			}
-		}()
+	// 去掉推理内容，只返回回答；出错时保留中断前的部分回答，供调用方尝试恢复
+	reasoning, text := splitThinking(resp.Text)
+	if resp.Reasoning != "" {
+		reasoning = strings.TrimSpace(resp.Reasoning + "\n" + reasoning)
 	}
-
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("启动llama.cpp失败: %w", err)
+	if c.debug && c.showThinking && reasoning != "" {
+		fmt.Println(i18n.T(i18n.AIDebugThinking, reasoning))
 	}
-
-	// 用于存储完整输出
-	var outputBuilder strings.Builder
-	// 使用扫描器来实时读取输出
-	scanner := bufio.NewScanner(stdoutPipe)
-
-	// 启动goroutine来处理输出
-	go func() {
-		for scanner.Scan() {
-			select {
-			case <-ctx.Done():
-				// 上下文被取消，立即退出
-				return
-			default:
-				line := scanner.Text()
-				outputBuilder.WriteString(line + "\n")
-
-				if c.debug {
-					fmt.Println(line) // debug 实时打印输出
-				}
-
-				if strings.Contains(line, stopMarker) {
-					cmd.Process.Kill()
-					return
-				}
-			}
-		}
-	}()
-
-	// 确保进程已结束
-	err = cmd.Wait()
-	if err != nil && ctx.Err() == context.DeadlineExceeded {
-		return outputBuilder.String(), fmt.Errorf("执行llama.cpp超时")
+	if err != nil {
+		return text, err
+	}
+	if c.debug && (resp.Usage.PromptTokens > 0 || resp.Usage.CompletionTokens > 0) {
+		fmt.Println(i18n.T(i18n.AIDebugUsage, resp.Usage.PromptTokens, resp.Usage.CompletionTokens))
 	}
 
-	return outputBuilder.String(), nil
+	return text, nil
 }
 
-// GenerateCommitMessage 根据diff生成commit message
-func (c *Client) GenerateCommitMessage(diffInfo *git.DiffInfo, onlyPrompt bool) (*CommitMessage, error) {
-	// 构建提示信息
-	prompt := buildDiffPrompt(diffInfo)
-
-	// 调用llama.cpp
-	response, err := c.callLlamaCpp(prompt, onlyPrompt)
+// getBackend 返回当前使用的推理后端，未显式设置时按名称创建
+func (c *Client) getBackend() (Backend, error) {
+	if c.backend != nil {
+		return c.backend, nil
+	}
+	backend, err := c.newBackend(c.backendName)
 	if err != nil {
 		return nil, err
 	}
+	if c.record {
+		backend = NewRecordingBackend(backend, c.replayDir)
+	}
 	if c.debug {
-		fmt.Println(response) // debug 响应
+		c.printModelInfo(backend)
 	}
+	c.backend = backend
+	return backend, nil
+}
 
-	// 解析AI响应
-	commitMsg, err := parseCommitMessage(response, diffInfo)
-	if err != nil {
-		return nil, err
+// printModelInfo 在debug模式下输出自动识别的模型信息
+func (c *Client) printModelInfo(backend Backend) {
+	architecture := i18n.T(i18n.AIDebugUnknown)
+	contextLength := 0
+	if metadata := c.modelMetadata(); metadata != nil {
+		architecture = metadata.Architecture()
+		contextLength = metadata.ContextLength()
 	}
-
-	return commitMsg, nil
+	templateName := i18n.T(i18n.AIDebugUnknown)
+	if template, err := c.chatTemplate(); err == nil {
+		templateName = template.Name
+	}
+	fmt.Println(i18n.T(i18n.AIDebugModelInfo, backend.Name(), architecture, contextLength, c.contextSize(), templateName))
 }
 
-// buildDiffPrompt 构建发送给AI的提示信息（用于生成commit message）
-func buildDiffPrompt(diffInfo *git.DiffInfo) string {
-	var sb strings.Builder
-
-	sb.WriteString("请根据以下Git差异信息，生成一个符合约定式提交规范(Conventional Commits)的提交信息。\n\n")
-
-	sb.WriteString("修改的文件：\n")
-	for i, file := range diffInfo.Files {
-		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, file))
+// GenerateCommitMessage 根据diff生成commit message
+// 模型回复无法解析时，会把错误的回复和修复要求一起发回模型，最多重试maxRetries次
+func (c *Client) GenerateCommitMessage(ctx context.Context, diffInfo *git.DiffInfo, onlyPrompt bool) (*CommitMessage, error) {
+	// 构建提示信息
+	prompt, err := c.commitPrompt(ctx, diffInfo, onlyPrompt)
+	if err != nil {
+		return nil, err
 	}
+	return c.generateCommitMessage(ctx, diffInfo, prompt, 0, onlyPrompt)
+}
 
-	sb.WriteString(fmt.Sprintf("\n添加行数: %d\n", diffInfo.Additions))
-	sb.WriteString(fmt.Sprintf("删除行数: %d\n", diffInfo.Deletions))
-
-	// 最大允许的diff内容长度
-	const maxDiffLength = 3000
-
-	// 如果diff内容不太长，则包含完整diff
-	if len(diffInfo.RawDiff) <= maxDiffLength {
-		sb.WriteString("\n差异详情：\n```\n")
-		sb.WriteString(diffInfo.RawDiff)
-		sb.WriteString("\n```\n")
-	} else {
-		// 对于长diff，尝试为每个文件提供一些上下文
-		sb.WriteString("\n差异详情（摘要）：\n")
-
-		// 按文件分割diff内容
-		fileDiffs := splitDiffByFile(diffInfo.RawDiff)
-
-		// 为每个文件分配一定的字符配额
-		quotaPerFile := maxDiffLength / len(fileDiffs)
-		if quotaPerFile < 500 {
-			quotaPerFile = 500 // 确保每个文件至少有500个字符
+// generateCommitMessage 生成并解析一条commit message，包含修复重试
+func (c *Client) generateCommitMessage(ctx context.Context, diffInfo *git.DiffInfo, basePrompt string, variant int, onlyPrompt bool) (*CommitMessage, error) {
+	prompt := basePrompt
+	params := c.generationParams(variant)
+	var previous *CommitMessage // 上一次不符合规范的结果
+
+	for attempt := 0; ; attempt++ {
+		// 调用推理后端
+		response, genErr := c.generate(ctx, prompt, variant, onlyPrompt)
+		// 被用户取消时直接退出，不再尝试恢复或重试
+		if ctx.Err() == context.Canceled {
+			return nil, ctx.Err()
+		}
+		if genErr != nil && response == "" {
+			if previous != nil {
+				return previous, nil
+			}
+			return nil, genErr
+		}
+		if c.debug {
+			fmt.Println(response) // debug 响应
 		}
 
-		totalUsed := 0
-		for i, fileDiff := range fileDiffs {
-			if i >= 10 { // 最多显示10个文件的diff
-				sb.WriteString("\n... 还有更多文件的变更未显示 ...\n")
-				break
+		// 解析AI响应
+		commitMsg, err := parseCommitMessage(response, diffInfo)
+		if err == nil {
+			commitMsg.Params = params
+			// 不符合规范且无法自动修正时，要求模型重新生成；重新生成失败时使用这一次的结果
+			unfixed := c.lintCommitMessage(commitMsg)
+			if len(unfixed) == 0 || attempt >= c.maxRetries || onlyPrompt {
+				return commitMsg, nil
 			}
+			if c.debug {
+				fmt.Println(i18n.T(i18n.AIDebugLintRetry, attempt+1, joinViolations(unfixed, i18n.Locale())))
+			}
+			previous = commitMsg
+			prompt = buildViolationPrompt(c.prompts(), basePrompt, response, unfixed)
+			continue
+		}
 
-			// 计算这个文件可以使用的字符数
-			availableChars := quotaPerFile
-			if totalUsed+availableChars > maxDiffLength {
-				availableChars = maxDiffLength - totalUsed
-				if availableChars < 300 { // 如果剩余空间太小，就不再显示更多文件
-					sb.WriteString("\n... 还有更多文件的变更未显示 ...\n")
-					break
+		// 生成中断（例如超时）时，尝试补全部分输出后再解析
+		if genErr != nil {
+			if salvaged, salvageErr := parseCommitMessage(completeJSON(response), diffInfo); salvageErr == nil {
+				if c.debug {
+					fmt.Println(i18n.T(i18n.AIDebugSalvaged, genErr))
 				}
+				salvaged.Params = params
+				c.lintCommitMessage(salvaged)
+				return salvaged, nil
 			}
+			if previous != nil {
+				return previous, nil
+			}
+			return nil, i18n.Wrap(genErr, i18n.AIPartialUnparsable, err)
+		}
 
-			// 提取文件名
-			fileName := extractFileName(fileDiff)
-			sb.WriteString(fmt.Sprintf("\n文件: %s\n```\n", fileName))
-
-			// 如果文件diff太长，则截断
-			if len(fileDiff) > availableChars {
-				// 尝试保留文件开头和结尾的一些内容
-				headLength := availableChars * 2 / 3
-				tailLength := availableChars - headLength - 20 // 20是省略号的长度
-
-				if headLength > 0 && tailLength > 0 {
-					sb.WriteString(fileDiff[:headLength])
-					sb.WriteString("\n... (内容过长已截断) ...\n")
-					if len(fileDiff) > len(fileDiff)-tailLength {
-						sb.WriteString(fileDiff[len(fileDiff)-tailLength:])
-					}
-				} else {
-					// 如果无法同时保留头尾，则只保留开头
-					sb.WriteString(fileDiff[:availableChars])
-					sb.WriteString("\n... (内容过长已截断) ...\n")
-				}
-			} else {
-				sb.WriteString(fileDiff)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || attempt >= c.maxRetries {
+			if previous != nil {
+				return previous, nil
 			}
+			return nil, err
+		}
+		if c.debug {
+			fmt.Println(i18n.T(i18n.AIDebugRetry, attempt+1, err))
+		}
+		prompt = buildRepairPrompt(c.prompts(), basePrompt, response, parseErr)
+	}
+}
 
-			sb.WriteString("\n```\n")
+// buildDiffPrompt 构建发送给AI的提示信息（用于生成commit message）
+// examples是提交历史示例，rules决定可选的类型和范围，diffBudget是差异详情可以使用的token数，countTokens用于统计token数
+func buildDiffPrompt(p *promptLang, diffInfo *git.DiffInfo, examples []string, rules *lint.Rules, diffBudget int, countTokens func(string) int) string {
+	var sb strings.Builder
 
-			totalUsed += min(len(fileDiff), availableChars) + 100 // 100是文件名和格式化的额外字符
+	sb.WriteString(p.task)
 
-			if totalUsed >= maxDiffLength {
-				sb.WriteString("\n... 还有更多文件的变更未显示 ...\n")
-				break
-			}
-		}
+	sb.WriteString(p.files)
+	for i, file := range diffInfo.Files {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, file))
 	}
 
-	sb.WriteString("\n请以JSON格式返回，包含以下字段：\n")
-	sb.WriteString("1. type: 提交类型（feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert等）\n")
-	sb.WriteString("2. scope: 影响范围（可选，例如组件名或文件名）\n")
-	sb.WriteString("3. subject: 简短描述（不超过50个字符）\n")
-	sb.WriteString("4. body: 详细描述（可选,不超过100个字符）\n")
-	sb.WriteString("\n重要：请只返回一个JSON对象，不要返回JSON数组。请综合所有变更生成一个最合适的提交信息。\n")
+	sb.WriteString(fmt.Sprintf(p.additions, diffInfo.Additions))
+	sb.WriteString(fmt.Sprintf(p.deletions, diffInfo.Deletions))
+
+	// 按token预算打包diff内容，超出时在hunk边界截断
+	sb.WriteString(packDiff(p, promptDiff(p, diffInfo), diffBudget, countTokens))
+
+	writeExamples(&sb, p, examples)
+	writeResponseInstructions(&sb, p, rules)
 
 	return sb.String()
 }
 
+// promptDiff 返回写入提示词的diff，只有未跟踪的文件时在文件列表前加上当前语言的标题
+func promptDiff(p *promptLang, diffInfo *git.DiffInfo) string {
+	if diffInfo.Untracked {
+		return p.untracked + diffInfo.RawDiff
+	}
+	return diffInfo.RawDiff
+}
+
+// writeResponseInstructions 写入要求模型以JSON格式返回提交信息的说明，可选的类型和范围由rules决定
+func writeResponseInstructions(sb *strings.Builder, p *promptLang, rules *lint.Rules) {
+	sb.WriteString(p.response)
+	sb.WriteString(fmt.Sprintf(p.fieldType, strings.Join(allowedTypes(rules), ", ")))
+	if scopes := allowedScopes(rules); len(scopes) > 0 {
+		sb.WriteString(fmt.Sprintf(p.fieldScopeEnum, strings.Join(scopes, ", ")))
+	} else {
+		sb.WriteString(p.fieldScope)
+	}
+	sb.WriteString(p.fieldSubject)
+	sb.WriteString(p.fieldBody)
+	sb.WriteString(p.onlyOne)
+}
+
 // splitDiffByFile 将完整的diff内容按文件分割
 func splitDiffByFile(rawDiff string) []string {
 	// 使用"diff --git"作为文件分隔符
@@ -317,12 +627,12 @@ func splitDiffByFile(rawDiff string) []string {
 	return result
 }
 
-// extractFileName 从文件diff中提取文件名
+// extractFileName 从文件diff中提取文件名，无法提取时返回界面语言的“未知文件”
 func extractFileName(fileDiff string) string {
 	// 尝试从"diff --git a/path/to/file b/path/to/file"格式中提取
 	lines := strings.Split(fileDiff, "\n")
 	if len(lines) == 0 {
-		return "未知文件"
+		return i18n.T(i18n.AIUnknownFile)
 	}
 
 	firstLine := lines[0]
@@ -342,15 +652,7 @@ func extractFileName(fileDiff string) string {
 		}
 	}
 
-	return "未知文件"
-}
-
-// min 返回两个整数中的较小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	return i18n.T(i18n.AIUnknownFile)
 }
 
 // parseCommitMessage 解析AI返回的commit message
@@ -360,16 +662,27 @@ func parseCommitMessage(response string, diffInfo *git.DiffInfo) (*CommitMessage
 	jsonEnd := strings.LastIndex(response, "}")
 
 	if jsonStart == -1 || jsonEnd == -1 || jsonEnd <= jsonStart {
-		// 如果没有找到有效的JSON，尝试创建一个基本的commit message
-		panic("没有找到有效的JSON，请重试")
+		return nil, &ParseError{Kind: ErrNoJSON, Output: response}
 	}
 
 	jsonStr := response[jsonStart : jsonEnd+1]
 
 	var commitMsg CommitMessage
 	if err := json.Unmarshal([]byte(jsonStr), &commitMsg); err != nil {
-		// 如果解析失败，创建一个基本的commit message
-		panic("没有找到有效的JSON，请重试")
+		// 语法正确但字段类型不对，属于不符合格式
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ParseError{Kind: ErrSchemaViolation, Detail: i18n.New(i18n.AIFieldType, typeErr.Field, typeErr.Type), Output: response}
+		}
+		return nil, &ParseError{Kind: ErrInvalidJSON, Detail: err, Output: response}
+	}
+
+	// 检查必填字段
+	if strings.TrimSpace(commitMsg.Type) == "" {
+		return nil, &ParseError{Kind: ErrSchemaViolation, Detail: i18n.New(i18n.AIMissingField, "type"), Output: response}
+	}
+	if strings.TrimSpace(commitMsg.Subject) == "" {
+		return nil, &ParseError{Kind: ErrSchemaViolation, Detail: i18n.New(i18n.AIMissingField, "subject"), Output: response}
 	}
 
 	// 添加原始diff信息
@@ -0,0 +1,230 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// scriptedBackend 是按脚本回复的假后端，记录收到的请求
+type scriptedBackend struct {
+	mu       sync.Mutex
+	requests []*Request
+	reply    func(req *Request, n int) (*Response, error) // n为第几次调用，从0开始
+}
+
+// Name 返回后端名称
+func (b *scriptedBackend) Name() string {
+	return "scripted"
+}
+
+// Generate 记录请求并按脚本回复
+func (b *scriptedBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	b.mu.Lock()
+	n := len(b.requests)
+	b.requests = append(b.requests, req)
+	b.mu.Unlock()
+	return b.reply(req, n)
+}
+
+// replies 返回依次回复texts的脚本，超出时重复最后一个
+func replies(texts ...string) func(req *Request, n int) (*Response, error) {
+	return func(req *Request, n int) (*Response, error) {
+		if n >= len(texts) {
+			n = len(texts) - 1
+		}
+		return &Response{Text: texts[n]}, nil
+	}
+}
+
+// newScriptedClient 返回使用假后端的客户端
+func newScriptedClient(backend Backend) *Client {
+	c := NewClient(false)
+	c.SetBackend(backend)
+	return c
+}
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		kind     error     // 期望的错误类型，nil表示解析成功
+		detail   i18n.Code // 期望的详细原因
+	}{
+		{name: "ok with surrounding text", response: "Sure:\n{\"type\": \"feat\", \"subject\": \"add cache\"}\nDone."},
+		{name: "no json", response: "I cannot help with that.", kind: ErrNoJSON},
+		{name: "braces in the wrong order", response: "} then {", kind: ErrNoJSON},
+		{name: "invalid json", response: `{"type": "feat", "subject": }`, kind: ErrInvalidJSON},
+		{name: "wrong field type", response: `{"type": "feat", "subject": "x", "breaking_changes": "yes"}`, kind: ErrSchemaViolation, detail: i18n.AIFieldType},
+		{name: "missing type", response: `{"subject": "add cache"}`, kind: ErrSchemaViolation, detail: i18n.AIMissingField},
+		{name: "blank subject", response: `{"type": "feat", "subject": "  "}`, kind: ErrSchemaViolation, detail: i18n.AIMissingField},
+	}
+
+	diffInfo := &git.DiffInfo{RawDiff: "diff --git a/x b/x\n"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commitMsg, err := parseCommitMessage(tt.response, diffInfo)
+			if tt.kind == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if commitMsg.Type != "feat" || commitMsg.Subject != "add cache" || commitMsg.RawDiff != diffInfo.RawDiff {
+					t.Errorf("unexpected commit message: %+v", commitMsg)
+				}
+				return
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, tt.kind) {
+				t.Fatalf("want %v, got %v", tt.kind, err)
+			}
+			if parseErr.Output != tt.response {
+				t.Errorf("Output: got %q", parseErr.Output)
+			}
+			if tt.detail != "" && i18n.CodeOf(parseErr.Detail) != tt.detail {
+				t.Errorf("Detail: want %s, got %v", tt.detail, parseErr.Detail)
+			}
+			// 修复提示使用提示词的语言
+			if parseErr.Localize(i18n.En) == parseErr.Localize(i18n.Zh) {
+				t.Errorf("error is not localized: %s", parseErr.Localize(i18n.En))
+			}
+		})
+	}
+}
+
+func TestCompleteJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		want    string
+	}{
+		{name: "open string", partial: `{"type": "feat", "subject": "add pars`, want: `{"type": "feat", "subject": "add pars"}`},
+		{name: "trailing comma", partial: "{\"type\": \"feat\",\n  ", want: `{"type": "feat"}`},
+		{name: "open key", partial: `{"type": "feat", "subj`, want: `{"type": "feat", "subj":null}`},
+		{name: "key without colon", partial: `{"type": "feat", "subject"`, want: `{"type": "feat", "subject":null}`},
+		{name: "key without value", partial: `{"type": "feat", "subject": `, want: `{"type": "feat", "subject":null}`},
+		{name: "nested arrays", partial: `{"a": [[1, 2], [3,`, want: `{"a": [[1, 2], [3]]}`},
+		{name: "nested object in array", partial: `{"a": [{"b": "c`, want: `{"a": [{"b": "c"}]}`},
+		{name: "dangling escape", partial: `{"s": "a\`, want: `{"s": "a\\"}`},
+		{name: "escaped quote", partial: `{"s": "say \"hi`, want: `{"s": "say \"hi"}`},
+		{name: "braces inside strings", partial: `{"s": "}{][", "t": [`, want: `{"s": "}{][", "t": []}`},
+		{name: "text before and after a complete object", partial: `Here: {"a": 1} and {"b": 2}`, want: `{"a": 1}`},
+		{name: "no object", partial: "no json", want: "no json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completeJSON(tt.partial)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if tt.name != "no object" && !json.Valid([]byte(got)) {
+				t.Errorf("result is not valid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func TestGenerateCommitMessageRepair(t *testing.T) {
+	backend := &scriptedBackend{reply: replies(
+		"I think this commit adds a cache.",
+		`{"type": "feat", "scope": "cache", "subject": "add a cache", "body": "", "breaking_changes": false}`,
+	)}
+	c := newScriptedClient(backend)
+
+	commitMsg, err := c.generateCommitMessage(context.Background(), &git.DiffInfo{}, "PROMPT", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitMsg.Subject != "add a cache" {
+		t.Errorf("got %+v", commitMsg)
+	}
+	if len(backend.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(backend.requests))
+	}
+
+	// 第二次请求是修复提示：原始提示、解析失败的原因、上一次的回复和修复要求
+	p := c.prompts()
+	repair := backend.requests[1].Prompt
+	for _, want := range []string{p.repairReason, (&ParseError{Kind: ErrNoJSON}).Localize(p.locale), p.repairOutput, "I think this commit adds a cache.", fmt.Sprintf(p.repairAsk, strings.Join(commitMessageFieldNames(), p.fieldSep))} {
+		if !strings.Contains(repair, want) {
+			t.Errorf("repair prompt does not contain %q:\n%s", want, repair)
+		}
+	}
+	if !strings.HasPrefix(repair, "PROMPT") {
+		t.Errorf("repair prompt does not start with the original prompt:\n%s", repair)
+	}
+}
+
+func TestGenerateCommitMessageRetriesExhausted(t *testing.T) {
+	backend := &scriptedBackend{reply: replies(`{"type": "feat", "subject": }`)}
+	c := newScriptedClient(backend)
+	c.SetMaxRetries(1)
+
+	_, err := c.generateCommitMessage(context.Background(), &git.DiffInfo{}, "PROMPT", 0, false)
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("want ErrInvalidJSON, got %v", err)
+	}
+	if len(backend.requests) != 2 {
+		t.Errorf("got %d requests, want 2", len(backend.requests))
+	}
+}
+
+func TestGenerateCommitMessageLintRetry(t *testing.T) {
+	backend := &scriptedBackend{reply: replies(
+		`{"type": "wip", "subject": "add a cache"}`,
+		`{"type": "feat", "subject": "add a cache"}`,
+	)}
+	c := newScriptedClient(backend)
+
+	commitMsg, err := c.generateCommitMessage(context.Background(), &git.DiffInfo{}, "PROMPT", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitMsg.Type != "feat" || len(backend.requests) != 2 {
+		t.Errorf("got %+v after %d requests", commitMsg, len(backend.requests))
+	}
+	if prompt := backend.requests[1].Prompt; !strings.Contains(prompt, c.prompts().violations) {
+		t.Errorf("second prompt does not report the violations:\n%s", prompt)
+	}
+}
+
+func TestGenerateCommitMessageSalvage(t *testing.T) {
+	t.Run("partial output", func(t *testing.T) {
+		backend := &scriptedBackend{reply: func(req *Request, n int) (*Response, error) {
+			return &Response{Text: `{"type": "fix", "subject": "handle timeouts", "body": "retry the requ`}, context.DeadlineExceeded
+		}}
+		c := newScriptedClient(backend)
+
+		commitMsg, err := c.generateCommitMessage(context.Background(), &git.DiffInfo{}, "PROMPT", 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commitMsg.Subject != "handle timeouts" || commitMsg.Body != "retry the requ" {
+			t.Errorf("got %+v", commitMsg)
+		}
+		// 补全成功时不再重试
+		if len(backend.requests) != 1 {
+			t.Errorf("got %d requests, want 1", len(backend.requests))
+		}
+	})
+
+	t.Run("unusable partial output", func(t *testing.T) {
+		backend := &scriptedBackend{reply: func(req *Request, n int) (*Response, error) {
+			return &Response{Text: `{"type": "fix", "subj`}, context.DeadlineExceeded
+		}}
+		c := newScriptedClient(backend)
+
+		_, err := c.generateCommitMessage(context.Background(), &git.DiffInfo{}, "PROMPT", 0, false)
+		if i18n.CodeOf(err) != i18n.AIPartialUnparsable || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want %s wrapping the timeout, got %v", i18n.AIPartialUnparsable, err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// 内置后端名称
+const (
+	BackendLlamaCLI    = "llama-cli"    // 直接调用llama-cli可执行文件
+	BackendLlamaServer = "llama-server" // 通过HTTP调用常驻的llama-server
+	BackendOpenAI      = "openai"       // OpenAI兼容的chat completions接口
+	BackendOllama      = "ollama"       // Ollama的/api/generate和/api/chat接口
+	BackendReplay      = "replay"       // 按提示词哈希回放录制好的回复，用于测试
+)
+
+// Backend 是推理后端的抽象，不同的推理运行时只需实现该接口
+type Backend interface {
+	// Name 返回后端名称
+	Name() string
+	// Generate 根据请求生成回复
+	Generate(ctx context.Context, req *Request) (*Response, error)
+}
+
+// ConcurrentBackend 由可以同时处理多个请求的后端实现
+type ConcurrentBackend interface {
+	// MaxConcurrency 返回最多可以同时处理的请求数
+	MaxConcurrency() int
+}
+
+// DefaultParallel 是没有指定并发数、也无法从服务获取slot数时的并发请求数，也是自动启动llama-server时的slot数
+const DefaultParallel = 4
+
+// maxConcurrency 返回后端最多可以同时处理的请求数，未实现ConcurrentBackend的后端只能串行调用
+func maxConcurrency(backend Backend) int {
+	if cb, ok := backend.(ConcurrentBackend); ok && cb.MaxConcurrency() > 1 {
+		return cb.MaxConcurrency()
+	}
+	return 1
+}
+
+// seedPtr 把种子转换为JSON字段，小于0时省略
+func seedPtr(seed int) *int {
+	if seed < 0 {
+		return nil
+	}
+	return &seed
+}
+
+// PromptRenderer 由需要自行拼接完整提示词的后端实现，用于--only-prompt和debug输出
+type PromptRenderer interface {
+	RenderPrompt(req *Request) string
+}
+
+// Request 表示一次生成请求
+type Request struct {
+	System  string            // 系统提示
+	Prompt  string            // 用户提示
+	Options Options           // 生成参数
+	Stream  func(text string) // 收到新生成的内容时回调，为nil时不流式输出
+}
+
+// Options 表示生成参数
+type Options struct {
+	Temperature float64 // 生成温度
+	MaxTokens   int     // 最大生成的token数
+	TopP        float64 // top-p
+	TopK        int     // top-k
+	MinP        float64 // min-p
+	Seed        int     // 随机种子，小于0表示随机
+	FreeForm    bool    // 是否允许自由文本输出（例如文件摘要），为true时后端不要求JSON格式
+	Think       bool    // 是否允许模型在回答前先推理（思考模式）
+	// 以下约束二选一，后端按各自支持的方式传给推理引擎
+	Grammar    string                 // GBNF语法
+	JSONSchema map[string]interface{} // JSON Schema
+}
+
+// Response 表示一次生成的结果
+type Response struct {
+	Text      string // 生成的文本
+	Reasoning string // 后端单独返回的推理内容，推理内容在Text中时为空
+	Usage     Usage  // token用量
+}
+
+// Usage 表示token用量，后端无法统计时为零值
+type Usage struct {
+	PromptTokens     int // 提示词token数
+	CompletionTokens int // 生成token数
+}
+
+// renderMessages 以可读的形式展示系统提示和用户提示
+func renderMessages(req *Request) string {
+	return fmt.Sprintf("[system]\n%s\n[user]\n%s\n", req.System, req.Prompt)
+}
+
+// BackendNames 返回所有内置后端名称
+func BackendNames() []string {
+	return []string{BackendLlamaCLI, BackendLlamaServer, BackendOpenAI, BackendOllama, BackendReplay}
+}
+
+// newBackend 根据名称和客户端配置创建后端
+func (c *Client) newBackend(name string) (Backend, error) {
+	template, err := c.chatTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(name) {
+	case "", BackendLlamaCLI:
+		backend := NewLlamaCLIBackend(c.llamaCppPath, c.modelPath, c.debug)
+		backend.SetChatTemplate(template)
+		backend.SetContextSize(c.contextSize())
+		backend.SetThreads(c.threads)
+		return backend, nil
+	case BackendLlamaServer:
+		backend := NewLlamaServerBackend(c.serverURL, c.serverEndpoint, c.debug)
+		backend.SetChatTemplate(template)
+		if c.spawnServer {
+			backend.EnableSpawn(c.llamaCppPath, c.modelPath, c.contextSize())
+			backend.SetThreads(c.threads)
+		}
+		backend.SetParallel(c.parallel)
+		return backend, nil
+	case BackendOpenAI:
+		backend := NewOpenAIBackend(c.openAIBaseURL, c.openAIAPIKey, c.modelName, c.openAITimeout)
+		backend.SetJSONMode(c.jsonMode)
+		backend.SetTemplateKwargs(c.openAIKwargs)
+		backend.SetParallel(c.parallel)
+		return backend, nil
+	case BackendOllama:
+		backend := NewOllamaBackend(c.ollamaURL, c.ollamaModel, c.ollamaEndpoint)
+		backend.SetJSONMode(c.jsonMode)
+		// Ollama中的模型不是modelPath，只传递显式设置的上下文长度
+		backend.SetContextSize(c.ctxSize)
+		backend.SetThreads(c.threads)
+		backend.SetParallel(c.parallel)
+		return backend, nil
+	case BackendReplay:
+		return NewReplayBackend(c.replayDir), nil
+	default:
+		return nil, i18n.New(i18n.AIUnsupportedBackend, name, strings.Join(BackendNames(), ", "))
+	}
+}
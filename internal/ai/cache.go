@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/cache"
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// promptVersion 是内置提示词的版本，修改内置提示词或diff的打包方式时递增，使旧的缓存失效
+const promptVersion = "1"
+
+// cachedMessage 是缓存中的一条commit message，Params和Violations在CommitMessage中不输出，需要单独保存
+type cachedMessage struct {
+	*CommitMessage
+	Params     *GenerationParams `json:"params"`
+	Violations []lint.Violation  `json:"violations,omitempty"`
+}
+
+// SetCache 设置保存生成结果的缓存，为nil时不使用缓存
+func (c *Client) SetCache(responseCache *cache.Cache) {
+	c.cache = responseCache
+}
+
+// cacheKey 计算生成结果的缓存键：diff、模型、提示词和生成参数都相同时结果相同。
+// 不使用缓存、未指定种子或无法计算时返回空字符串
+func (c *Client) cacheKey(diffInfo *git.DiffInfo, n int) string {
+	// 未指定种子时每次运行的种子都不同，不能返回上一次的结果
+	if c.cache == nil || c.seed < 0 {
+		return ""
+	}
+	model, err := c.modelIdentity()
+	if err != nil {
+		if c.debug {
+			fmt.Println(i18n.T(i18n.AIDebugCacheSkipped, err))
+		}
+		return ""
+	}
+
+	// 自定义模板的内容
+	tmplPath := findPromptTemplate(c.promptDirs, commitTemplateName, c.lang)
+	tmplContent := ""
+	if tmplPath != "" {
+		if data, err := os.ReadFile(tmplPath); err == nil {
+			tmplContent = string(data)
+		}
+	}
+	chatTemplate := ""
+	if template, err := c.chatTemplate(); err == nil {
+		chatTemplate = template.Name
+	}
+
+	// 线程数和超时时间不影响生成结果
+	params := *c.generationParams(0)
+	params.Model = model
+	params.Threads = 0
+	params.Timeout = ""
+	paramsJSON, _ := json.Marshal(params)
+	rulesJSON, _ := json.Marshal(c.rules)
+
+	return cache.Key(
+		promptVersion,
+		diffInfo.RawDiff,
+		strings.Join(diffInfo.Files, "\n"),
+		string(paramsJSON),
+		c.lang,
+		tmplContent,
+		chatTemplate,
+		strings.Join(c.examples(diffInfo.Files), "\n"),
+		c.constrain,
+		c.mapReduce,
+		strconv.FormatBool(c.jsonMode),
+		strconv.Itoa(n),
+		string(rulesJSON),
+	)
+}
+
+// modelIdentity 返回标识所用模型的字符串：本地模型文件使用内容的哈希，远程模型使用地址和模型名称
+func (c *Client) modelIdentity() (string, error) {
+	switch strings.ToLower(c.backendName) {
+	case BackendLlamaCLI, "":
+		return c.cache.FileHash(c.modelPath)
+	case BackendLlamaServer:
+		// 连接已经运行的llama-server时，加载的模型不一定是modelPath
+		if !c.spawnServer {
+			return c.serverURL + "\x00" + c.modelName, nil
+		}
+		return c.cache.FileHash(c.modelPath)
+	case BackendOpenAI:
+		return c.openAIBaseURL + "\x00" + c.modelName, nil
+	case BackendOllama:
+		return c.ollamaURL + "\x00" + c.ollamaModel, nil
+	default:
+		return c.backendName + "\x00" + c.replayDir, nil
+	}
+}
+
+// loadCandidates 从缓存中读取生成结果，没有缓存时返回nil
+func (c *Client) loadCandidates(key string, diffInfo *git.DiffInfo) []*CommitMessage {
+	var cached []cachedMessage
+	found, err := c.cache.Get(key, &cached)
+	if err != nil && c.debug {
+		fmt.Println(i18n.T(i18n.AIDebugCacheFailed, err))
+	}
+	if !found || len(cached) == 0 {
+		return nil
+	}
+
+	commitMsgs := make([]*CommitMessage, 0, len(cached))
+	for _, entry := range cached {
+		if entry.CommitMessage == nil {
+			return nil
+		}
+		entry.CommitMessage.RawDiff = diffInfo.RawDiff
+		entry.CommitMessage.Params = entry.Params
+		entry.CommitMessage.Violations = entry.Violations
+		commitMsgs = append(commitMsgs, entry.CommitMessage)
+	}
+	if c.debug {
+		fmt.Println(i18n.T(i18n.AIDebugCacheHit, key))
+	}
+	return commitMsgs
+}
+
+// saveCandidates 把生成结果写入缓存，写入失败不影响本次生成
+func (c *Client) saveCandidates(key string, commitMsgs []*CommitMessage) {
+	cached := make([]cachedMessage, len(commitMsgs))
+	for i, commitMsg := range commitMsgs {
+		cached[i] = cachedMessage{CommitMessage: commitMsg, Params: commitMsg.Params, Violations: commitMsg.Violations}
+	}
+	if err := c.cache.Put(key, cached); err != nil && c.debug {
+		fmt.Println(i18n.T(i18n.AIDebugCacheFailed, err))
+	}
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/cache"
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// newCachingClient 返回使用临时缓存目录和假后端的客户端，种子固定
+func newCachingClient(t *testing.T, backend Backend) *Client {
+	c := NewClient(false)
+	c.SetBackendName(BackendOpenAI)
+	c.SetBackend(backend)
+	c.SetCache(cache.NewCache(t.TempDir()))
+	c.SetSeed(1)
+	return c
+}
+
+func TestCacheKey(t *testing.T) {
+	diffInfo := &git.DiffInfo{Files: []string{"a.go"}, RawDiff: fileDiff("a.go", "added line", 2)}
+	base := newCachingClient(t, &scriptedBackend{}).cacheKey(diffInfo, 1)
+	if base == "" {
+		t.Fatal("no cache key")
+	}
+
+	tmplDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmplDir, commitTemplateName+".tmpl"), []byte("{{.Diff}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func(c *Client, diffInfo *git.DiffInfo)
+	}{
+		{name: "diff", change: func(c *Client, diffInfo *git.DiffInfo) { diffInfo.RawDiff += "+another line\n" }},
+		{name: "files", change: func(c *Client, diffInfo *git.DiffInfo) { diffInfo.Files = append(diffInfo.Files, "b.go") }},
+		{name: "temperature", change: func(c *Client, diffInfo *git.DiffInfo) { c.SetTemperature(0.7) }},
+		{name: "seed", change: func(c *Client, diffInfo *git.DiffInfo) { c.SetSeed(2) }},
+		{name: "lang", change: func(c *Client, diffInfo *git.DiffInfo) { c.SetLang(LangEn) }},
+		{name: "template", change: func(c *Client, diffInfo *git.DiffInfo) { c.SetPromptDirs([]string{tmplDir}) }},
+		{name: "rules", change: func(c *Client, diffInfo *git.DiffInfo) { c.SetRules(lint.DefaultRules([]string{"feat"})) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCachingClient(t, &scriptedBackend{})
+			changed := *diffInfo
+			changed.Files = append([]string(nil), diffInfo.Files...)
+			tt.change(c, &changed)
+			if key := c.cacheKey(&changed, 1); key == "" || key == base {
+				t.Errorf("key did not change: %q", key)
+			}
+		})
+	}
+
+	// 线程数不影响生成结果
+	c := newCachingClient(t, &scriptedBackend{})
+	c.SetThreads(8)
+	if key := c.cacheKey(diffInfo, 1); key != base {
+		t.Errorf("threads should not change the key")
+	}
+	// 随机种子不使用缓存
+	c.SetSeed(-1)
+	if key := c.cacheKey(diffInfo, 1); key != "" {
+		t.Errorf("random seed: got key %q, want none", key)
+	}
+}
+
+func TestGenerateCandidatesCache(t *testing.T) {
+	diffInfo := &git.DiffInfo{Files: []string{"a.go"}, RawDiff: fileDiff("a.go", "added line", 2)}
+	for _, tt := range []struct {
+		name     string
+		seed     int
+		requests int // 两次生成向后端发送的请求数
+	}{
+		{name: "fixed seed", seed: 1, requests: 1},
+		{name: "random seed", seed: -1, requests: 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &scriptedBackend{reply: replies(`{"type": "feat", "scope": "cache", "subject": "add a cache"}`)}
+			c := newCachingClient(t, backend)
+			c.SetSeed(tt.seed)
+
+			for i := 0; i < 2; i++ {
+				commitMsgs, err := c.GenerateCandidates(context.Background(), diffInfo, 1, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(commitMsgs) != 1 || commitMsgs[0].Subject != "add a cache" || commitMsgs[0].RawDiff != diffInfo.RawDiff {
+					t.Errorf("run %d: got %+v", i, commitMsgs)
+				}
+			}
+			if len(backend.requests) != tt.requests {
+				t.Errorf("got %d requests, want %d", len(backend.requests), tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// candidateTemperatureStep 是每个候选相对上一个候选提高的生成温度
+const candidateTemperatureStep = 0.3
+
+// GenerateCandidates 生成n条候选commit message，后端支持时并发生成，
+// 结果去重后按得分从高到低排序；部分候选失败时只返回成功的候选。
+// 设置了缓存时，相同的diff、模型和参数直接返回缓存的结果；
+// 启用了启发式生成或模型生成失败并启用了回退时，返回启发式规则生成的结果
+func (c *Client) GenerateCandidates(ctx context.Context, diffInfo *git.DiffInfo, n int, onlyPrompt bool) ([]*CommitMessage, error) {
+	if c.heuristic && !onlyPrompt {
+		return []*CommitMessage{c.HeuristicCommitMessage(diffInfo)}, nil
+	}
+	if onlyPrompt {
+		return c.generateCandidates(ctx, diffInfo, n, onlyPrompt)
+	}
+
+	key := c.cacheKey(diffInfo, n)
+	if key != "" {
+		if commitMsgs := c.loadCandidates(key, diffInfo); commitMsgs != nil {
+			return commitMsgs, nil
+		}
+	}
+	commitMsgs, err := c.generateCandidates(ctx, diffInfo, n, onlyPrompt)
+	if err != nil {
+		return c.fallbackCandidates(ctx, diffInfo, err)
+	}
+	if key != "" {
+		c.saveCandidates(key, commitMsgs)
+	}
+	return commitMsgs, nil
+}
+
+// generateCandidates 调用推理后端生成n条候选commit message
+func (c *Client) generateCandidates(ctx context.Context, diffInfo *git.DiffInfo, n int, onlyPrompt bool) ([]*CommitMessage, error) {
+	if n <= 1 || onlyPrompt {
+		commitMsg, err := c.GenerateCommitMessage(ctx, diffInfo, onlyPrompt)
+		if err != nil {
+			return nil, err
+		}
+		return []*CommitMessage{commitMsg}, nil
+	}
+
+	backend, err := c.getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := c.commitPrompt(ctx, diffInfo, false)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*CommitMessage, n)
+	errs := make([]error, n)
+
+	// 用带缓冲的channel限制并发数
+	sem := make(chan struct{}, maxConcurrency(backend))
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(variant int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[variant], errs[variant] = c.generateCommitMessage(ctx, diffInfo, prompt, variant, false)
+		}(i)
+	}
+	wg.Wait()
+
+	candidates := []*CommitMessage{}
+	var firstErr error
+	for i, commitMsg := range results {
+		if errs[i] != nil {
+			if c.debug {
+				fmt.Println(i18n.T(i18n.AIDebugCandidateFailed, i+1, errs[i]))
+			}
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		candidates = append(candidates, commitMsg)
+	}
+	if len(candidates) == 0 {
+		return nil, firstErr
+	}
+
+	return RankCandidates(candidates, c.rules), nil
+}
+
+// RankCandidates 对候选去重，并按得分从高到低排序，得分相同时保持原有顺序，rules决定可选的类型
+func RankCandidates(candidates []*CommitMessage, rules *lint.Rules) []*CommitMessage {
+	seen := map[string]bool{}
+	unique := []*CommitMessage{}
+	for _, commitMsg := range candidates {
+		key := candidateKey(commitMsg)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, commitMsg)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		return ScoreCommitMessage(unique[i], rules) > ScoreCommitMessage(unique[j], rules)
+	})
+	return unique
+}
+
+// candidateKey 返回用于去重的键，忽略大小写和首尾空白
+func candidateKey(commitMsg *CommitMessage) string {
+	return strings.ToLower(strings.Join([]string{
+		strings.TrimSpace(commitMsg.Type),
+		strings.TrimSpace(commitMsg.Scope),
+		strings.TrimSpace(commitMsg.Subject),
+		strings.TrimSpace(commitMsg.Body),
+		fmt.Sprint(commitMsg.BreakingChanges),
+	}, "\x00"))
+}
+
+// ScoreCommitMessage 按有效性和长度给commit message打分，分数越高越好，rules决定可选的类型
+func ScoreCommitMessage(commitMsg *CommitMessage, rules *lint.Rules) int {
+	score := 0
+
+	// 类型是否为规则允许的类型
+	for _, t := range allowedTypes(rules) {
+		if commitMsg.Type == t {
+			score += 10
+			break
+		}
+	}
+
+	// 主题：非空、不超过50个字符、不以句号结尾
+	subjectLen := len([]rune(strings.TrimSpace(commitMsg.Subject)))
+	if subjectLen > 0 {
+		score += 10
+	}
+	if subjectLen > 50 {
+		score -= (subjectLen - 50) / 5
+	}
+	if strings.HasSuffix(commitMsg.Subject, ".") || strings.HasSuffix(commitMsg.Subject, "。") {
+		score -= 2
+	}
+
+	// 正文：有正文更好，但不超过100个字符
+	bodyLen := len([]rune(strings.TrimSpace(commitMsg.Body)))
+	if bodyLen > 0 {
+		score += 3
+	}
+	if bodyLen > 100 {
+		score -= (bodyLen - 100) / 10
+	}
+
+	// 仍不符合规范的地方
+	score -= 5 * len(lint.Unfixed(commitMsg.Violations))
+
+	return score
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// concurrentBackend 是可以同时处理多个请求的假后端，记录同时处理的最大请求数
+type concurrentBackend struct {
+	scriptedBackend
+	limit  int // MaxConcurrency的返回值
+	mu     sync.Mutex
+	active int // 正在处理的请求数
+	peak   int // 同时处理的最大请求数
+}
+
+// MaxConcurrency 返回limit
+func (b *concurrentBackend) MaxConcurrency() int {
+	return b.limit
+}
+
+// Generate 记录并发数，稍作等待使请求重叠
+func (b *concurrentBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	b.mu.Lock()
+	b.active++
+	if b.active > b.peak {
+		b.peak = b.active
+	}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.active--
+		b.mu.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	return b.scriptedBackend.Generate(ctx, req)
+}
+
+func TestScoreCommitMessageUsesRules(t *testing.T) {
+	wip := &CommitMessage{Type: "wip", Subject: "spike the parser"}
+	feat := &CommitMessage{Type: "feat", Subject: "spike the parser"}
+
+	// 默认规则使用CommitTypes
+	if ScoreCommitMessage(feat, nil) <= ScoreCommitMessage(wip, nil) {
+		t.Error("default rules: a conventional type should score higher than an unknown type")
+	}
+
+	// 自定义规则中的类型得分，不在规则中的类型不得分
+	rules := lint.DefaultRules([]string{"wip", "fix"})
+	if ScoreCommitMessage(wip, rules) <= ScoreCommitMessage(feat, rules) {
+		t.Error("custom rules: a type from the rules should score higher than one outside them")
+	}
+
+	ranked := RankCandidates([]*CommitMessage{feat, wip}, rules)
+	if ranked[0] != wip {
+		t.Errorf("got %q ranked first, want %q", ranked[0].Type, wip.Type)
+	}
+}
+
+func TestRankCandidatesDedup(t *testing.T) {
+	first := &CommitMessage{Type: "feat", Scope: "cache", Subject: "add a cache", Body: "body"}
+	candidates := []*CommitMessage{
+		{Type: "feat", Subject: "x"},
+		first,
+		// 只有大小写和首尾空白不同
+		{Type: "Feat ", Scope: "Cache", Subject: " add a Cache", Body: "body "},
+		{Type: "feat", Scope: "cache", Subject: "add a cache", Body: "body", BreakingChanges: true},
+	}
+	ranked := RankCandidates(candidates, nil)
+	if len(ranked) != 3 {
+		t.Fatalf("got %d candidates, want 3", len(ranked))
+	}
+	if ranked[0] != first {
+		t.Errorf("got %+v ranked first, want %+v", ranked[0], first)
+	}
+	for _, commitMsg := range ranked {
+		if commitMsg == candidates[2] {
+			t.Error("duplicate candidate was kept instead of the first one")
+		}
+	}
+}
+
+func TestGenerateCandidatesConcurrency(t *testing.T) {
+	const n = 5
+	for _, limit := range []int{1, 3, n} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			c := NewClient(false)
+			failTemperature := c.requestOptions(2).Temperature
+			backend := &concurrentBackend{limit: limit}
+			// 第3个候选失败，其他候选的主题各不相同
+			backend.reply = func(req *Request, call int) (*Response, error) {
+				if req.Options.Temperature == failTemperature {
+					return nil, errors.New("model crashed")
+				}
+				return &Response{Text: fmt.Sprintf(`{"type": "feat", "scope": "", "subject": "variant at %.1f", "body": "", "breaking_changes": false}`, req.Options.Temperature)}, nil
+			}
+			c.SetBackend(backend)
+
+			commitMsgs, err := c.generateCandidates(context.Background(), &git.DiffInfo{Files: []string{"a.go"}, RawDiff: fileDiff("a.go", "added line", 1)}, n, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(commitMsgs) != n-1 {
+				t.Errorf("got %d candidates, want %d", len(commitMsgs), n-1)
+			}
+			if backend.peak > limit {
+				t.Errorf("%d requests ran at once, limit %d", backend.peak, limit)
+			}
+			if limit > 1 && backend.peak < 2 {
+				t.Errorf("requests did not run concurrently with limit %d", limit)
+			}
+		})
+	}
+
+	// 所有候选都失败时返回错误
+	backend := &concurrentBackend{limit: 2}
+	backend.reply = func(req *Request, call int) (*Response, error) {
+		return nil, errors.New("model crashed")
+	}
+	c := newScriptedClient(backend)
+	if _, err := c.generateCandidates(context.Background(), &git.DiffInfo{}, 3, false); err == nil {
+		t.Error("want an error when every candidate fails")
+	}
+}
@@ -0,0 +1,35 @@
+package ai
+
+import "github.com/rust17/AImmit/internal/i18n"
+
+// 解析模型回复时的错误类型，可以用errors.Is判断
+var (
+	ErrNoJSON          = i18n.New(i18n.AINoJSON)
+	ErrInvalidJSON     = i18n.New(i18n.AIInvalidJSON)
+	ErrSchemaViolation = i18n.New(i18n.AISchemaViolation)
+)
+
+// ParseError 表示模型回复无法解析为CommitMessage
+type ParseError struct {
+	Kind   error  // 错误类型：ErrNoJSON、ErrInvalidJSON或ErrSchemaViolation
+	Detail error  // 详细原因，可以为空
+	Output string // 模型的原始回复
+}
+
+// Error 用当前的界面语言返回错误描述
+func (e *ParseError) Error() string {
+	return e.Localize(i18n.Locale())
+}
+
+// Localize 用指定的界面语言返回错误描述，修复提示中使用与提示词相同的语言
+func (e *ParseError) Localize(locale string) string {
+	if e.Detail == nil {
+		return i18n.Localize(e.Kind, locale)
+	}
+	return i18n.Localize(e.Kind, locale) + ": " + i18n.Localize(e.Detail, locale)
+}
+
+// Unwrap 返回错误类型，使errors.Is(err, ErrNoJSON)等判断生效
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
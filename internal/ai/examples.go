@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/git"
+)
+
+// maxExamples 是提示词中最多包含的提交历史示例数
+const maxExamples = 3
+
+// conventionalHeader 匹配约定式提交的标题行，例如 feat(api)!: 添加接口
+var conventionalHeader = regexp.MustCompile(`^([a-z]+)(\([^()\s]+\))?!?: \S`)
+
+// SetHistory 设置仓库最近的提交，生成提示词时从中选出与本次变更相关的约定式提交作为示例
+func (c *Client) SetHistory(commits []git.Commit) {
+	c.history = commits
+}
+
+// examples 返回提示词中的提交历史示例
+func (c *Client) examples(files []string) []string {
+	return selectExamples(c.history, files, allowedTypes(c.rules), maxExamples)
+}
+
+// selectExamples 从commits中选出最多max个符合约定式提交规范、类型在types中、且与files修改了相同文件或目录的提交，
+// 返回它们的标题行。修改相同文件多的提交优先，相同时较新的提交优先
+func selectExamples(commits []git.Commit, files, types []string, max int) []string {
+	type candidate struct {
+		header string
+		score  int
+	}
+	candidates := []candidate{}
+	seen := map[string]bool{}
+	for _, commit := range commits {
+		header, _, _ := strings.Cut(commit.Message, "\n")
+		header = strings.TrimSpace(header)
+		if seen[header] || !isConventionalHeader(header, types) {
+			continue
+		}
+		if score := pathOverlap(commit.Files, files); score > 0 {
+			seen[header] = true
+			candidates = append(candidates, candidate{header: header, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	examples := []string{}
+	for i := 0; i < len(candidates) && i < max; i++ {
+		examples = append(examples, candidates[i].header)
+	}
+	return examples
+}
+
+// isConventionalHeader 判断标题行是否符合约定式提交规范且类型在types中
+func isConventionalHeader(header string, types []string) bool {
+	match := conventionalHeader.FindStringSubmatch(header)
+	if match == nil {
+		return false
+	}
+	for _, t := range types {
+		if match[1] == t {
+			return true
+		}
+	}
+	return false
+}
+
+// pathOverlap 计算两组文件的重合程度：修改了同一个文件记2分，修改了同一目录下的文件记1分
+func pathOverlap(commitFiles, files []string) int {
+	score := 0
+	for _, file := range files {
+		best := 0
+		for _, commitFile := range commitFiles {
+			if commitFile == file {
+				best = 2
+				break
+			}
+			if dir := filepath.Dir(file); dir != "." && dir == filepath.Dir(commitFile) {
+				best = 1
+			}
+		}
+		score += best
+	}
+	return score
+}
+
+// writeExamples 写入提交历史示例，没有示例时不写入
+func writeExamples(sb *strings.Builder, p *promptLang, examples []string) {
+	if len(examples) == 0 {
+		return
+	}
+	sb.WriteString(p.examples)
+	for _, example := range examples {
+		sb.WriteString("- " + example + "\n")
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+)
+
+// recentCommits 是选择示例测试使用的提交历史，从新到旧排列
+var recentCommits = []git.Commit{
+	{Message: "fix(cache): handle a missing cache dir\n\nDetails.", Files: []string{"internal/cache/cache.go"}},
+	{Message: "Merge branch 'main'", Files: []string{"internal/cache/cache.go"}},
+	{Message: "feat(ai): add map-reduce summaries", Files: []string{"internal/ai/mapreduce.go", "internal/ai/ai.go"}},
+	{Message: "wip: try another prompt", Files: []string{"internal/ai/ai.go"}},
+	{Message: "docs: describe the cache", Files: []string{"README.md"}},
+	{Message: "refactor(ai): split the prompt builder", Files: []string{"internal/ai/ai.go", "internal/cache/cache.go"}},
+	{Message: "fix(cache): handle a missing cache dir", Files: []string{"internal/cache/cache.go"}},
+	{Message: "chore: bump go", Files: []string{"go.mod"}},
+	{Message: "test(ai): cover packing", Files: []string{"internal/ai/pack_test.go"}},
+}
+
+func TestSelectExamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		max   int
+		want  []string
+	}{
+		{
+			// 修改相同文件多的优先，相同时较新的优先；重复的标题只保留一次
+			name:  "same files first",
+			files: []string{"internal/ai/ai.go", "internal/cache/cache.go"},
+			max:   5,
+			want: []string{
+				"refactor(ai): split the prompt builder",
+				"fix(cache): handle a missing cache dir",
+				"feat(ai): add map-reduce summaries",
+				"test(ai): cover packing",
+			},
+		},
+		{
+			name:  "capped",
+			files: []string{"internal/ai/ai.go", "internal/cache/cache.go"},
+			max:   2,
+			want:  []string{"refactor(ai): split the prompt builder", "fix(cache): handle a missing cache dir"},
+		},
+		{
+			// 同一目录下的文件也算重合
+			name:  "same directory",
+			files: []string{"internal/ai/tokens.go"},
+			max:   maxExamples,
+			want:  []string{"feat(ai): add map-reduce summaries", "refactor(ai): split the prompt builder", "test(ai): cover packing"},
+		},
+		{
+			// 根目录下不同的文件不算重合
+			name:  "root files",
+			files: []string{"CHANGELOG.md"},
+			max:   maxExamples,
+			want:  []string{},
+		},
+		{
+			name:  "same root file",
+			files: []string{"README.md"},
+			max:   maxExamples,
+			want:  []string{"docs: describe the cache"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectExamples(recentCommits, tt.files, CommitTypes, tt.max)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 类型不在允许的类型中的提交不作为示例
+	got := selectExamples(recentCommits, []string{"internal/cache/cache.go"}, []string{"feat", "refactor"}, maxExamples)
+	if want := []string{"refactor(ai): split the prompt builder"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("types: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/git"
+)
+
+// BackendHeuristic 是启发式生成在生成参数中使用的后端名称，它不调用模型
+const BackendHeuristic = "heuristic"
+
+// heuristicMaxNames 是subject中最多列出的文件名数，文件更多时只写文件数
+const heuristicMaxNames = 3
+
+// heuristicMaxSubject 是subject的最大字符数
+const heuristicMaxSubject = 50
+
+// 文件在diff中的状态
+const (
+	fileModified = iota
+	fileAdded
+	fileDeleted
+	fileRenamed
+)
+
+// 按文件路径推断的类别
+const (
+	categoryCode  = ""
+	categoryTest  = "test"
+	categoryDocs  = "docs"
+	categoryBuild = "build"
+	categoryCI    = "ci"
+)
+
+// buildFiles 是构建和依赖管理相关的文件名
+var buildFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true,
+	"Makefile": true, "Dockerfile": true, "CMakeLists.txt": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.toml": true, "Cargo.lock": true, "pyproject.toml": true, "requirements.txt": true,
+	"pom.xml": true, "build.gradle": true,
+}
+
+// docExts 是文档文件的扩展名
+var docExts = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true}
+
+// genericDirs 是不适合作为scope的通用目录名
+var genericDirs = map[string]bool{"internal": true, "pkg": true, "cmd": true, "src": true, "lib": true, "app": true}
+
+// SetHeuristic 设置是否不调用模型，直接用启发式规则生成commit message
+func (c *Client) SetHeuristic(heuristic bool) {
+	c.heuristic = heuristic
+}
+
+// SetFallback 设置模型生成失败（模型文件不存在、超时等）时是否改用启发式规则生成
+func (c *Client) SetFallback(fallback bool) {
+	c.fallback = fallback
+}
+
+// fallbackCandidates 在模型生成失败时返回启发式生成的结果，用户取消或未启用回退时返回原来的错误
+func (c *Client) fallbackCandidates(ctx context.Context, diffInfo *git.DiffInfo, err error) ([]*CommitMessage, error) {
+	if !c.fallback || ctx.Err() == context.Canceled {
+		return nil, err
+	}
+	commitMsg := c.HeuristicCommitMessage(diffInfo)
+	commitMsg.Params.Fallback = err.Error()
+	return []*CommitMessage{commitMsg}, nil
+}
+
+// HeuristicCommitMessage 不调用模型，根据修改的文件推断commit message：
+// type由文件类别和增删情况决定，scope是文件的公共目录，subject列出修改的文件
+func (c *Client) HeuristicCommitMessage(diffInfo *git.DiffInfo) *CommitMessage {
+	p := c.prompts()
+	statuses := fileStatuses(diffInfo)
+	files := diffInfo.Files
+	if len(files) == 0 {
+		for _, file := range splitDiffByFile(diffInfo.RawDiff) {
+			files = append(files, extractFileName(file))
+		}
+	}
+
+	// 推断的类型不是允许的类型时（例如commitlint配置限定了类型），使用chore或第一个允许的类型
+	commitType := heuristicType(files, statuses)
+	if types := allowedTypes(c.rules); !containsString(types, commitType) {
+		commitType = types[0]
+		if containsString(types, "chore") {
+			commitType = "chore"
+		}
+	}
+
+	commitMsg := &CommitMessage{
+		Type:    commitType,
+		Scope:   heuristicScope(files),
+		Subject: heuristicSubject(p, files, statuses),
+		Body:    fmt.Sprintf(p.heurBody, len(files), diffInfo.Additions, diffInfo.Deletions),
+		RawDiff: diffInfo.RawDiff,
+		Params:  &GenerationParams{Backend: BackendHeuristic},
+	}
+	c.lintCommitMessage(commitMsg)
+	return commitMsg
+}
+
+// fileStatuses 从diff的文件头中读取新增、删除和重命名的文件，未跟踪的文件都是新增的文件
+func fileStatuses(diffInfo *git.DiffInfo) map[string]int {
+	statuses := map[string]int{}
+	if diffInfo.Untracked {
+		for _, file := range diffInfo.Files {
+			statuses[file] = fileAdded
+		}
+		return statuses
+	}
+
+	for _, fileDiff := range splitDiffByFile(diffInfo.RawDiff) {
+		file := parseDiffFile(fileDiff)
+		switch {
+		case strings.Contains(file.header, "\nnew file mode"):
+			statuses[file.name] = fileAdded
+		case strings.Contains(file.header, "\ndeleted file mode"):
+			statuses[file.name] = fileDeleted
+		case strings.Contains(file.header, "\nrename from"):
+			statuses[file.name] = fileRenamed
+		}
+	}
+	return statuses
+}
+
+// fileCategory 按路径推断文件的类别
+func fileCategory(file string) string {
+	base := path.Base(file)
+	ext := strings.ToLower(path.Ext(base))
+	switch {
+	case strings.HasPrefix(file, ".github/workflows/") || strings.HasPrefix(file, ".circleci/") ||
+		base == ".gitlab-ci.yml" || base == ".travis.yml" || base == "Jenkinsfile":
+		return categoryCI
+	case buildFiles[base]:
+		return categoryBuild
+	case strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") || hasDir(file, "test", "tests", "testdata", "__tests__"):
+		return categoryTest
+	case docExts[ext] || hasDir(file, "docs", "doc") ||
+		strings.HasPrefix(base, "README") || strings.HasPrefix(base, "CHANGELOG") || strings.HasPrefix(base, "LICENSE"):
+		return categoryDocs
+	}
+	return categoryCode
+}
+
+// hasDir 判断文件是否在名为dirs之一的目录中
+func hasDir(file string, dirs ...string) bool {
+	parts := strings.Split(path.Dir(file), "/")
+	for _, part := range parts {
+		for _, dir := range dirs {
+			if part == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// heuristicType 推断提交类型：所有文件属于同一类别（测试、文档、构建、CI）时使用该类别，
+// 否则只新增文件为feat，只重命名文件为refactor，其他为chore
+func heuristicType(files []string, statuses map[string]int) string {
+	if len(files) == 0 {
+		return "chore"
+	}
+
+	category := fileCategory(files[0])
+	for _, file := range files[1:] {
+		if fileCategory(file) != category {
+			category = categoryCode
+			break
+		}
+	}
+	if category != categoryCode {
+		return category
+	}
+
+	switch commonStatus(files, statuses) {
+	case fileAdded:
+		return "feat"
+	case fileRenamed:
+		return "refactor"
+	}
+	return "chore"
+}
+
+// containsString 判断list中是否有s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// commonStatus 返回所有文件共同的状态，状态不同时返回fileModified
+func commonStatus(files []string, statuses map[string]int) int {
+	if len(files) == 0 {
+		return fileModified
+	}
+	status := statuses[files[0]]
+	for _, file := range files[1:] {
+		if statuses[file] != status {
+			return fileModified
+		}
+	}
+	return status
+}
+
+// heuristicScope 使用文件公共目录的最后一级作为scope，没有公共目录或目录名过于通用时为空
+func heuristicScope(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	common := strings.Split(path.Dir(files[0]), "/")
+	for _, file := range files[1:] {
+		parts := strings.Split(path.Dir(file), "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	// 隐藏目录（例如.github）中的文件不使用scope
+	if len(common) == 0 || strings.HasPrefix(common[0], ".") {
+		return ""
+	}
+
+	scope := strings.ToLower(common[len(common)-1])
+	if genericDirs[scope] {
+		return ""
+	}
+	return scope
+}
+
+// heuristicSubject 根据文件的增删情况和文件名生成subject，文件较多或文件名过长时只写文件数
+func heuristicSubject(p *promptLang, files []string, statuses map[string]int) string {
+	format := p.heurUpdate
+	switch commonStatus(files, statuses) {
+	case fileAdded:
+		format = p.heurAdd
+	case fileDeleted:
+		format = p.heurRemove
+	}
+
+	if len(files) > 0 && len(files) <= heuristicMaxNames {
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = path.Base(file)
+		}
+		subject := fmt.Sprintf(format, strings.Join(names, p.heurSep))
+		if len([]rune(subject)) <= heuristicMaxSubject {
+			return subject
+		}
+	}
+	return fmt.Sprintf(format, fmt.Sprintf(p.heurFiles, len(files)))
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+func TestHeuristicType(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		statuses map[string]int
+		want     string
+	}{
+		{name: "tests only", files: []string{"internal/ai/ai_test.go", "testdata/replay/a.json"}, want: "test"},
+		{name: "docs only", files: []string{"README.md", "docs/usage.txt"}, want: "docs"},
+		{name: "go.mod only", files: []string{"go.mod"}, want: "build"},
+		{name: "ci only", files: []string{".github/workflows/test.yml"}, want: "ci"},
+		{name: "mixed categories", files: []string{"README.md", "go.mod"}, want: "chore"},
+		{name: "added code", files: []string{"a.go", "b.go"}, statuses: map[string]int{"a.go": fileAdded, "b.go": fileAdded}, want: "feat"},
+		{name: "renamed code", files: []string{"a.go"}, statuses: map[string]int{"a.go": fileRenamed}, want: "refactor"},
+		{name: "modified code", files: []string{"a.go", "b.go"}, statuses: map[string]int{"a.go": fileAdded}, want: "chore"},
+		{name: "no files", want: "chore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heuristicType(tt.files, tt.statuses); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicTypeNotAllowed(t *testing.T) {
+	diffInfo := &git.DiffInfo{Files: []string{"README.md"}}
+	for _, tt := range []struct {
+		types []string
+		want  string
+	}{
+		{types: []string{"feat", "fix", "docs", "chore"}, want: "docs"},
+		{types: []string{"feat", "fix", "chore"}, want: "chore"},
+		{types: []string{"feat", "fix"}, want: "feat"},
+	} {
+		c := NewClient(false)
+		c.SetRules(lint.DefaultRules(tt.types))
+		if got := c.HeuristicCommitMessage(diffInfo).Type; got != tt.want {
+			t.Errorf("types %v: got %s, want %s", tt.types, got, tt.want)
+		}
+	}
+}
+
+func TestHeuristicScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "common dir", files: []string{"internal/ai/ai.go", "internal/ai/pack.go"}, want: "ai"},
+		{name: "deepest common dir", files: []string{"web/UI/button.ts", "web/UI/forms/input.ts"}, want: "ui"},
+		{name: "generic dir", files: []string{"internal/ai/ai.go", "internal/cache/cache.go"}, want: ""},
+		{name: "cmd", files: []string{"cmd/main.go"}, want: ""},
+		{name: "hidden dir", files: []string{".github/workflows/test.yml"}, want: ""},
+		{name: "root files", files: []string{"main.go", "go.mod"}, want: ""},
+		{name: "no common dir", files: []string{"api/a.go", "web/b.go"}, want: ""},
+		{name: "no files", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heuristicScope(tt.files); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicSubject(t *testing.T) {
+	en := promptLangs[LangEn]
+	tests := []struct {
+		name     string
+		files    []string
+		statuses map[string]int
+		want     string
+	}{
+		{name: "file names", files: []string{"internal/ai/ai.go", "README.md"}, want: "update ai.go, README.md"},
+		{name: "added", files: []string{"a.go"}, statuses: map[string]int{"a.go": fileAdded}, want: "add a.go"},
+		{name: "deleted", files: []string{"a.go"}, statuses: map[string]int{"a.go": fileDeleted}, want: "remove a.go"},
+		{name: "too many files", files: []string{"a.go", "b.go", "c.go", "d.go"}, want: "update 4 files"},
+		{name: "names too long", files: []string{strings.Repeat("long_name_", 4) + ".go", strings.Repeat("other_name_", 4) + ".go"}, want: "update 2 files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := heuristicSubject(en, tt.files, tt.statuses)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n > heuristicMaxSubject {
+				t.Errorf("subject has %d characters, more than %d", n, heuristicMaxSubject)
+			}
+		})
+	}
+
+	if got := heuristicSubject(promptLangs[LangZh], []string{"a.go", "b.go"}, nil); got != "更新a.go、b.go" {
+		t.Errorf("zh: got %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// sendJSON 发送JSON请求，状态码不是200时返回错误，调用方负责关闭响应体
+func sendJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.AIMarshalRequest)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.AICreateRequest)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, i18n.New(i18n.AIRequestTimeout, url)
+		}
+		return nil, i18n.Wrap(err, i18n.AIRequestFailed, url)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, i18n.New(i18n.AIHTTPStatus, url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// postJSON 发送JSON请求并解析JSON响应
+func postJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	resp, err := sendJSON(ctx, httpClient, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return i18n.Wrap(err, i18n.AIReadResponse)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return i18n.Wrap(err, i18n.AIParseResponse)
+	}
+	return nil
+}
+
+// postStream 发送JSON请求，并逐行读取流式响应（SSE或NDJSON），onLine返回true时停止读取
+func postStream(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body interface{}, onLine func(line string) (bool, error)) error {
+	resp, err := sendJSON(ctx, httpClient, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			done, cbErr := onLine(line)
+			if cbErr != nil {
+				return cbErr
+			}
+			if done {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return i18n.New(i18n.AIRequestTimeout, url)
+			}
+			return i18n.Wrap(err, i18n.AIReadStream)
+		}
+	}
+}
+
+// sseData 提取SSE中data行的内容，不是data行时返回false
+func sseData(line string) (string, bool) {
+	if !strings.HasPrefix(line, "data:") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "data:")), true
+}
+
+// chatCompletionChunk 是chat completions接口流式响应中的一块
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// streamChat 以流式方式调用chat completions接口，出错时返回已经生成的部分
+func streamChat(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body chatCompletionRequest, stream func(string)) (*Response, error) {
+	body.Stream = true
+	body.StreamOptions = &streamOptions{IncludeUsage: true}
+
+	var text, reasoning strings.Builder
+	var usage Usage
+	err := postStream(ctx, httpClient, url, headers, body, func(line string) (bool, error) {
+		data, ok := sseData(line)
+		if !ok {
+			return false, nil
+		}
+		if data == "[DONE]" {
+			return true, nil
+		}
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, i18n.Wrap(err, i18n.AIParseStream)
+		}
+		if len(chunk.Choices) > 0 {
+			delta := chunk.Choices[0].Delta
+			// 单独返回的推理内容不实时输出
+			reasoning.WriteString(delta.ReasoningContent)
+			if delta.Content != "" {
+				text.WriteString(delta.Content)
+				stream(delta.Content)
+			}
+		}
+		if chunk.Usage != nil {
+			usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		return false, nil
+	})
+	return &Response{Text: text.String(), Reasoning: reasoning.String(), Usage: usage}, err
+}
@@ -0,0 +1,249 @@
+package ai
+
+import (
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// 生成的commit message使用的语言
+const (
+	LangZh        = "zh"        // 中文
+	LangEn        = "en"        // 英文
+	LangJa        = "ja"        // 日文
+	LangBilingual = "bilingual" // 英文主题，中文正文，bilingual:<语言>指定正文的语言
+)
+
+// bilingualBodyLangs 是双语模式下正文可以使用的语言
+var bilingualBodyLangs = []string{LangZh, LangJa}
+
+// DefaultLang 是默认的commit message语言
+const DefaultLang = LangZh
+
+// Langs 返回支持的语言
+func Langs() []string {
+	langs := []string{LangZh, LangEn, LangJa, LangBilingual}
+	for _, body := range bilingualBodyLangs {
+		langs = append(langs, LangBilingual+":"+body)
+	}
+	return langs
+}
+
+// BodyLang 返回body使用的语言，双语模式下为正文的语言
+func BodyLang(lang string) string {
+	if lang == LangBilingual {
+		return LangZh
+	}
+	if body := strings.TrimPrefix(lang, LangBilingual+":"); body != lang {
+		return body
+	}
+	return lang
+}
+
+// ValidateLang 检查语言是否受支持
+func ValidateLang(lang string) error {
+	if _, ok := promptLangs[lang]; !ok {
+		return i18n.New(i18n.AIUnsupportedLang, lang, strings.Join(Langs(), ", "))
+	}
+	return nil
+}
+
+// promptLang 是某种语言的提示词，带%的字段是格式字符串
+type promptLang struct {
+	system         string // 系统提示
+	task           string // 生成commit message的任务说明
+	summaryTask    string // 分段总结时生成commit message的任务说明
+	fileSummary    string // 总结单个文件变更的任务说明
+	files          string // 修改的文件列表标题
+	additions      string // 添加行数，%d
+	deletions      string // 删除行数，%d
+	summaries      string // 各文件变更摘要的标题
+	examples       string // 提交历史示例的标题
+	diff           string // 完整diff的标题
+	diffSummary    string // 截断后diff的标题
+	untracked      string // 只有未跟踪的文件时文件列表的标题
+	file           string // 单个文件diff的标题，%s为文件名
+	omittedFiles   string // 省略的文件，%d为数量，%s为文件名列表
+	omittedHunks   string // 省略的hunk，%d为hunk数和行数
+	truncatedHunk  string // 截断的hunk，%d为未显示的行数
+	response       string // 返回格式说明的标题
+	fieldType      string // type字段说明，%s为可选类型
+	fieldScope     string // scope字段说明
+	fieldScopeEnum string // 限定了范围时的scope字段说明，%s为可选范围
+	fieldSubject   string // subject字段说明
+	fieldBody      string // body字段说明
+	onlyOne        string // 只返回一个JSON对象的要求
+	repairReason   string // 修复提示：上一次回复无法解析的原因
+	repairOutput   string // 修复提示：上一次的回复
+	violations     string // 修复提示：上一次的回复不符合规范
+	repairAsk      string // 修复提示：修复要求，%s为字段列表
+	fieldSep       string // 字段列表的分隔符
+	locale         string // 修复提示中解析错误的语言
+	// 以下用于不使用模型的启发式生成
+	heurAdd    string // 添加文件的subject，%s为文件
+	heurRemove string // 删除文件的subject，%s为文件
+	heurUpdate string // 修改文件的subject，%s为文件
+	heurFiles  string // 文件较多时代替文件名，%d为文件数
+	heurSep    string // 文件名之间的分隔符
+	heurBody   string // body，%d为文件数、添加行数和删除行数
+}
+
+// promptLangs 是各语言的提示词
+var promptLangs = map[string]*promptLang{
+	LangZh: {
+		system:         "你是一个专业的代码提交分析助手，擅长总结Git提交历史和生成规范的commit message。可以拼接技术术语英文，不过请尽可能用中文回答。",
+		task:           "请根据以下Git差异信息，生成一个符合约定式提交规范(Conventional Commits)的提交信息。\n\n",
+		summaryTask:    "请根据以下Git变更信息，生成一个符合约定式提交规范(Conventional Commits)的提交信息。\n\n",
+		fileSummary:    "请用一到两句话总结以下文件的代码变更，说明改了什么以及目的，只返回总结内容，不要返回JSON或代码。\n",
+		files:          "修改的文件：\n",
+		additions:      "\n添加行数: %d\n",
+		deletions:      "删除行数: %d\n",
+		summaries:      "\n各文件的变更摘要：\n",
+		examples:       "\n本仓库中修改过相同文件的提交，请参考它们的scope、大小写和时态：\n",
+		diff:           "\n差异详情：\n",
+		diffSummary:    "\n差异详情（摘要）：\n",
+		untracked:      "未跟踪的文件:\n",
+		file:           "\n文件: %s\n",
+		omittedFiles:   "\n... 还有%d个文件的变更因长度限制未显示: %s ...\n",
+		omittedHunks:   "... (省略了%d个hunk，共%d行) ...\n",
+		truncatedHunk:  "... (该hunk还有%d行未显示) ...\n",
+		response:       "\n请以JSON格式返回，包含以下字段：\n",
+		fieldType:      "1. type: 提交类型（%s）\n",
+		fieldScope:     "2. scope: 影响范围（可选，例如组件名或文件名）\n",
+		fieldScopeEnum: "2. scope: 影响范围（可选，只能是以下之一：%s）\n",
+		fieldSubject:   "3. subject: 简短描述（不超过50个字符）\n",
+		fieldBody:      "4. body: 详细描述（可选,不超过100个字符）\n",
+		onlyOne:        "\n重要：请只返回一个JSON对象，不要返回JSON数组。请综合所有变更生成一个最合适的提交信息。\n",
+		repairReason:   "\n你上一次的回复无法解析，原因：",
+		repairOutput:   "\n上一次的回复：\n",
+		violations:     "\n你上一次的回复不符合提交规范：\n",
+		repairAsk:      "请修正上述问题，只返回一个合法的JSON对象，必须包含%s字段，不要包含任何其他内容。\n",
+		fieldSep:       "、",
+		locale:         i18n.Zh,
+		heurAdd:        "添加%s",
+		heurRemove:     "删除%s",
+		heurUpdate:     "更新%s",
+		heurFiles:      "%d个文件",
+		heurSep:        "、",
+		heurBody:       "修改了%d个文件，添加%d行，删除%d行",
+	},
+	LangEn: {
+		system:         "You are a professional assistant for analyzing code changes, skilled at summarizing Git history and writing well-formed commit messages. Always answer in English.",
+		task:           "Based on the following Git diff, write a commit message that follows the Conventional Commits specification.\n\n",
+		summaryTask:    "Based on the following Git changes, write a commit message that follows the Conventional Commits specification.\n\n",
+		fileSummary:    "Summarize the code changes in the following file in one or two sentences, explaining what changed and why. Reply with the summary only, no JSON or code.\n",
+		files:          "Changed files:\n",
+		additions:      "\nLines added: %d\n",
+		deletions:      "Lines deleted: %d\n",
+		summaries:      "\nPer-file change summaries:\n",
+		examples:       "\nEarlier commits in this repository that touched the same files; follow their style for scope, casing and tense:\n",
+		diff:           "\nDiff:\n",
+		diffSummary:    "\nDiff (abridged):\n",
+		untracked:      "Untracked files:\n",
+		file:           "\nFile: %s\n",
+		omittedFiles:   "\n... changes to %d more files omitted due to length: %s ...\n",
+		omittedHunks:   "... (%d hunks omitted, %d lines) ...\n",
+		truncatedHunk:  "... (%d more lines in this hunk not shown) ...\n",
+		response:       "\nReply in JSON with the following fields:\n",
+		fieldType:      "1. type: commit type (%s)\n",
+		fieldScope:     "2. scope: affected area (optional, e.g. a component or file name)\n",
+		fieldScopeEnum: "2. scope: affected area (optional, must be one of: %s)\n",
+		fieldSubject:   "3. subject: short imperative description in English (at most 50 characters)\n",
+		fieldBody:      "4. body: detailed description in English (optional, at most 100 characters)\n",
+		onlyOne:        "\nImportant: reply with a single JSON object, not a JSON array. Combine all changes into the single most fitting commit message.\n",
+		repairReason:   "\nYour previous reply could not be parsed: ",
+		repairOutput:   "\nPrevious reply:\n",
+		violations:     "\nYour previous reply violates the commit message rules:\n",
+		repairAsk:      "Fix the problem above and reply with a single valid JSON object containing the fields %s, and nothing else.\n",
+		fieldSep:       ", ",
+		locale:         i18n.En,
+		heurAdd:        "add %s",
+		heurRemove:     "remove %s",
+		heurUpdate:     "update %s",
+		heurFiles:      "%d files",
+		heurSep:        ", ",
+		heurBody:       "Files changed: %d, additions: %d, deletions: %d",
+	},
+	LangJa: {
+		system:         "あなたはコード変更を分析するプロのアシスタントで、Gitの履歴を要約し、規約に沿ったcommit messageを書くことが得意です。技術用語は英語のままで構いませんが、できるだけ日本語で回答してください。",
+		task:           "以下のGitの差分をもとに、Conventional Commitsの規約に従ったコミットメッセージを作成してください。\n\n",
+		summaryTask:    "以下のGitの変更内容をもとに、Conventional Commitsの規約に従ったコミットメッセージを作成してください。\n\n",
+		fileSummary:    "以下のファイルのコード変更を一、二文で要約し、何をなぜ変更したかを説明してください。要約のみを返し、JSONやコードは返さないでください。\n",
+		files:          "変更されたファイル：\n",
+		additions:      "\n追加行数: %d\n",
+		deletions:      "削除行数: %d\n",
+		summaries:      "\n各ファイルの変更の要約：\n",
+		examples:       "\nこのリポジトリで同じファイルを変更した過去のコミットです。scope、大文字・小文字、時制はこれらに合わせてください：\n",
+		diff:           "\n差分：\n",
+		diffSummary:    "\n差分（抜粋）：\n",
+		untracked:      "未追跡のファイル:\n",
+		file:           "\nファイル: %s\n",
+		omittedFiles:   "\n... 長さの制限により、ほかに%d個のファイルの変更を省略しました: %s ...\n",
+		omittedHunks:   "... (%d個のhunk、計%d行を省略) ...\n",
+		truncatedHunk:  "... (このhunkの残り%d行は省略) ...\n",
+		response:       "\n以下のフィールドを含むJSON形式で返してください：\n",
+		fieldType:      "1. type: コミットの種類（%s）\n",
+		fieldScope:     "2. scope: 影響範囲（任意、例：コンポーネント名やファイル名）\n",
+		fieldScopeEnum: "2. scope: 影響範囲（任意、次のいずれか：%s）\n",
+		fieldSubject:   "3. subject: 日本語の簡潔な説明（50文字以内）\n",
+		fieldBody:      "4. body: 日本語の詳細な説明（任意、100文字以内）\n",
+		onlyOne:        "\n重要：JSON配列ではなく、JSONオブジェクトを一つだけ返してください。すべての変更をまとめて最も適切なコミットメッセージを一つ作成してください。\n",
+		repairReason:   "\n前回の回答を解析できませんでした。理由：",
+		repairOutput:   "\n前回の回答：\n",
+		violations:     "\n前回の回答はコミットメッセージの規約に違反しています：\n",
+		repairAsk:      "上記の問題を修正し、%sフィールドを含む有効なJSONオブジェクトを一つだけ返してください。ほかの内容は含めないでください。\n",
+		fieldSep:       "、",
+		// 解析错误没有日文翻译，使用英文
+		locale:     i18n.En,
+		heurAdd:    "%sを追加",
+		heurRemove: "%sを削除",
+		heurUpdate: "%sを更新",
+		heurFiles:  "%d個のファイル",
+		heurSep:    "、",
+		heurBody:   "%d個のファイルを変更（追加%d行、削除%d行）",
+	},
+}
+
+// bilingualPrompt 是双语模式下与正文语言不同的提示词
+type bilingualPrompt struct {
+	system       string // 系统提示
+	fieldSubject string // subject字段说明
+	fieldBody    string // body字段说明
+}
+
+// bilingualPrompts 是双语模式下各正文语言的提示词，subject使用英文
+var bilingualPrompts = map[string]bilingualPrompt{
+	LangZh: {
+		system:       "你是一个专业的代码提交分析助手，擅长总结Git提交历史和生成规范的commit message。subject请使用英文，body请使用中文。",
+		fieldSubject: "3. subject: 英文的简短描述，使用祈使句（不超过50个字符）\n",
+		fieldBody:    "4. body: 中文的详细描述（可选,不超过100个字符）\n",
+	},
+	LangJa: {
+		system:       "あなたはコード変更を分析するプロのアシスタントで、Gitの履歴を要約し、規約に沿ったcommit messageを書くことが得意です。subjectは英語で、bodyは日本語で書いてください。",
+		fieldSubject: "3. subject: 英語の簡潔な説明、命令形で（50文字以内）\n",
+		fieldBody:    "4. body: 日本語の詳細な説明（任意、100文字以内）\n",
+	},
+}
+
+func init() {
+	// 双语模式：英文主题，其余提示词与正文的语言相同，启发式生成的主题也使用英文
+	en := promptLangs[LangEn]
+	for _, body := range bilingualBodyLangs {
+		bilingual := *promptLangs[body]
+		b := bilingualPrompts[body]
+		bilingual.system, bilingual.fieldSubject, bilingual.fieldBody = b.system, b.fieldSubject, b.fieldBody
+		bilingual.heurAdd, bilingual.heurRemove, bilingual.heurUpdate = en.heurAdd, en.heurRemove, en.heurUpdate
+		bilingual.heurFiles, bilingual.heurSep = en.heurFiles, en.heurSep
+		promptLangs[LangBilingual+":"+body] = &bilingual
+	}
+	// 不指定正文的语言时使用中文
+	promptLangs[LangBilingual] = promptLangs[LangBilingual+":"+LangZh]
+}
+
+// prompts 返回当前语言的提示词，不支持的语言使用默认语言
+func (c *Client) prompts() *promptLang {
+	if p, ok := promptLangs[c.lang]; ok {
+		return p
+	}
+	return promptLangs[DefaultLang]
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+)
+
+func TestBilingualLangs(t *testing.T) {
+	tests := []struct {
+		lang     string
+		bodyLang string
+		body     string // body字段说明中应该包含的内容
+	}{
+		{lang: LangBilingual, bodyLang: LangZh, body: "中文的详细描述"},
+		{lang: "bilingual:zh", bodyLang: LangZh, body: "中文的详细描述"},
+		{lang: "bilingual:ja", bodyLang: LangJa, body: "日本語の詳細な説明"},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateLang(tt.lang); err != nil {
+			t.Fatalf("%s: %v", tt.lang, err)
+		}
+		if got := BodyLang(tt.lang); got != tt.bodyLang {
+			t.Errorf("%s: got body language %q, want %q", tt.lang, got, tt.bodyLang)
+		}
+		p := (&Client{lang: tt.lang}).prompts()
+		if !strings.Contains(p.fieldBody, tt.body) {
+			t.Errorf("%s: body instruction is not in the body language: %q", tt.lang, p.fieldBody)
+		}
+		// 其余提示词与正文的语言相同，启发式生成的主题使用英文
+		if base := promptLangs[tt.bodyLang]; p.task != base.task || p.untracked != base.untracked {
+			t.Errorf("%s: prompts do not follow the body language", tt.lang)
+		}
+		if p.heurAdd != promptLangs[LangEn].heurAdd {
+			t.Errorf("%s: heuristic subject is not in English: %q", tt.lang, p.heurAdd)
+		}
+	}
+
+	for _, lang := range []string{"bilingual:en", "bilingual:", "bilingual:fr"} {
+		if err := ValidateLang(lang); err == nil {
+			t.Errorf("%s: want an error", lang)
+		}
+	}
+}
+
+func TestBuildDiffPromptUntracked(t *testing.T) {
+	countTokens := func(s string) int { return len(s) }
+	diffInfo := &git.DiffInfo{Files: []string{"a.go"}, RawDiff: "a.go\n", Untracked: true}
+	for lang, want := range map[string]string{LangZh: "未跟踪的文件:\na.go", LangEn: "Untracked files:\na.go", LangJa: "未追跡のファイル:\na.go"} {
+		prompt := buildDiffPrompt(promptLangs[lang], diffInfo, nil, nil, 1000, countTokens)
+		if !strings.Contains(prompt, want) {
+			t.Errorf("%s: prompt does not contain %q:\n%s", lang, want, prompt)
+		}
+	}
+
+	// 有差异时不加标题
+	diffInfo.Untracked = false
+	if prompt := buildDiffPrompt(promptLangs[LangEn], diffInfo, nil, nil, 1000, countTokens); strings.Contains(prompt, "Untracked") {
+		t.Errorf("prompt contains the untracked header:\n%s", prompt)
+	}
+}
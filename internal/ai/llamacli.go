@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// LlamaCLIBackend 通过直接调用llama-cli可执行文件生成回复
+type LlamaCLIBackend struct {
+	llamaCppPath string        // llama.cpp可执行文件所在目录
+	modelPath    string        // 模型文件路径
+	debug        bool          // 是否开启debug模式
+	template     *ChatTemplate // 对话模板
+	contextSize  int           // 上下文长度，为0时使用llama-cli的默认值
+	threads      int           // 推理线程数，为0时使用llama-cli的默认值
+}
+
+// NewLlamaCLIBackend 创建一个新的llama-cli后端
+func NewLlamaCLIBackend(llamaCppPath, modelPath string, debug bool) *LlamaCLIBackend {
+	return &LlamaCLIBackend{
+		llamaCppPath: llamaCppPath,
+		modelPath:    modelPath,
+		debug:        debug,
+		template:     chatTemplates[DefaultChatTemplate],
+	}
+}
+
+// SetChatTemplate 设置对话模板
+func (b *LlamaCLIBackend) SetChatTemplate(template *ChatTemplate) {
+	b.template = template
+}
+
+// SetContextSize 设置上下文长度，为0时使用llama-cli的默认值
+func (b *LlamaCLIBackend) SetContextSize(size int) {
+	b.contextSize = size
+}
+
+// SetThreads 设置推理线程数，为0时使用llama-cli的默认值
+func (b *LlamaCLIBackend) SetThreads(threads int) {
+	b.threads = threads
+}
+
+// Name 返回后端名称
+func (b *LlamaCLIBackend) Name() string {
+	return BackendLlamaCLI
+}
+
+// RenderPrompt 拼接发送给llama-cli的完整提示词
+func (b *LlamaCLIBackend) RenderPrompt(req *Request) string {
+	return b.template.Render(req)
+}
+
+// Generate 调用llama-cli生成回复
+func (b *LlamaCLIBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	fullPrompt := b.RenderPrompt(req)
+	opts := req.Options
+
+	// 构建llama.cpp命令行参数
+	args := []string{
+		"-m", b.modelPath,
+		"-p", fullPrompt,
+		"--no-display-prompt",
+		"--n-predict", fmt.Sprintf("%d", opts.MaxTokens),
+		// Qwen3-1.7B-Q6_K.gguf 模型最佳参数
+		"--min-p", fmt.Sprintf("%.2f", opts.MinP),
+		"--temp", fmt.Sprintf("%.2f", opts.Temperature),
+		"--top-p", fmt.Sprintf("%.2f", opts.TopP),
+		"--top-k", fmt.Sprintf("%d", opts.TopK),
+		"--seed", fmt.Sprintf("%d", opts.Seed),
+	}
+	if b.contextSize > 0 {
+		args = append(args, "--ctx-size", fmt.Sprintf("%d", b.contextSize))
+	}
+	if b.threads > 0 {
+		args = append(args, "--threads", fmt.Sprintf("%d", b.threads))
+	}
+	// 约束解码，保证输出是合法的JSON
+	if opts.Grammar != "" {
+		args = append(args, "--grammar", opts.Grammar)
+	} else if opts.JSONSchema != nil {
+		schema, err := json.Marshal(opts.JSONSchema)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.AIMarshalSchema)
+		}
+		args = append(args, "--json-schema", string(schema))
+	}
+	cmd := exec.CommandContext(ctx, b.llamaCppPath+"/llama-cli", args...)
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+b.llamaCppPath)
+	// 取消时结束整个进程组，避免llama-cli的子进程残留
+	setProcessGroup(cmd)
+
+	var debug io.Writer
+	if b.debug {
+		debug = os.Stdout
+	}
+	text, err := runProcess(ctx, cmd, b.template.Stop, req.Stream, debug)
+
+	// llama-cli 不报告token用量
+	return &Response{Text: text}, err
+}
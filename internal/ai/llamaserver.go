@@ -0,0 +1,442 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// llama-server支持的接口
+const (
+	ServerEndpointCompletion = "completion" // 原生/completion接口，由aimmit拼接提示词模板
+	ServerEndpointChat       = "chat"       // OpenAI兼容的/v1/chat/completions接口，由服务端套用模板
+)
+
+// DefaultServerURL 是llama-server的默认地址
+const DefaultServerURL = "http://127.0.0.1:8080"
+
+// LlamaServerBackend 通过HTTP调用常驻的llama-server，模型只需加载一次
+type LlamaServerBackend struct {
+	serverURL    string        // llama-server地址
+	endpoint     string        // 使用的接口（completion或chat）
+	llamaCppPath string        // llama.cpp可执行文件所在目录，用于启动llama-server
+	modelPath    string        // 模型文件路径，用于启动llama-server
+	contextSize  int           // 启动llama-server时的上下文长度，为0时使用默认值
+	threads      int           // 启动llama-server时的推理线程数，为0时使用默认值
+	parallel     int           // 同时发送的请求数和启动llama-server时的slot数，为0时从服务获取
+	slots        int           // 从/props获取的slot数，获取失败时为0
+	slotsOnce    sync.Once     // 只获取一次slot数
+	spawn        bool          // 服务未运行时是否自动启动
+	debug        bool          // 是否开启debug模式
+	template     *ChatTemplate // 对话模板（completion接口使用）
+	httpClient   *http.Client  // HTTP客户端
+}
+
+// NewLlamaServerBackend 创建一个新的llama-server后端
+func NewLlamaServerBackend(serverURL, endpoint string, debug bool) *LlamaServerBackend {
+	if serverURL == "" {
+		serverURL = DefaultServerURL
+	}
+	if endpoint == "" {
+		endpoint = ServerEndpointCompletion
+	}
+	return &LlamaServerBackend{
+		serverURL:  strings.TrimRight(serverURL, "/"),
+		endpoint:   endpoint,
+		debug:      debug,
+		template:   chatTemplates[DefaultChatTemplate],
+		httpClient: &http.Client{},
+	}
+}
+
+// EnableSpawn 开启自动启动：服务未运行时从llamaCppPath启动llama-server并加载modelPath，
+// 启动的服务在aimmit退出后继续运行，供后续调用复用
+func (b *LlamaServerBackend) EnableSpawn(llamaCppPath, modelPath string, contextSize int) {
+	b.spawn = true
+	b.llamaCppPath = llamaCppPath
+	b.modelPath = modelPath
+	b.contextSize = contextSize
+}
+
+// SetThreads 设置启动llama-server时的推理线程数，为0时使用默认值
+func (b *LlamaServerBackend) SetThreads(threads int) {
+	b.threads = threads
+}
+
+// SetParallel 设置同时发送的请求数，自动启动时作为llama-server的slot数（--parallel），
+// 为0时从运行中的服务获取slot数
+func (b *LlamaServerBackend) SetParallel(parallel int) {
+	b.parallel = parallel
+}
+
+// Name 返回后端名称
+func (b *LlamaServerBackend) Name() string {
+	return BackendLlamaServer
+}
+
+// MaxConcurrency llama-server按slot并行处理请求，超出slot数的请求会排队。
+// 没有设置并发数时使用服务的slot数，服务未运行时使用自动启动的slot数
+func (b *LlamaServerBackend) MaxConcurrency() int {
+	if b.parallel > 0 {
+		return b.parallel
+	}
+	b.slotsOnce.Do(func() {
+		b.slots = b.totalSlots()
+	})
+	if b.slots > 0 {
+		return b.slots
+	}
+	return DefaultParallel
+}
+
+// totalSlots 从/props获取llama-server的slot数，失败时返回0
+func (b *LlamaServerBackend) totalSlots() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, b.serverURL+"/props", nil)
+	if err != nil {
+		return 0
+	}
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	var props struct {
+		TotalSlots int `json:"total_slots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&props); err != nil {
+		return 0
+	}
+	return props.TotalSlots
+}
+
+// RenderPrompt 拼接发送给llama-server的提示词
+func (b *LlamaServerBackend) RenderPrompt(req *Request) string {
+	if b.endpoint == ServerEndpointChat {
+		return renderMessages(req)
+	}
+	return b.template.Render(req)
+}
+
+// SetChatTemplate 设置completion接口使用的对话模板
+func (b *LlamaServerBackend) SetChatTemplate(template *ChatTemplate) {
+	b.template = template
+}
+
+// Generate 调用llama-server生成回复
+func (b *LlamaServerBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if err := b.ensureRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	switch b.endpoint {
+	case ServerEndpointCompletion:
+		return b.complete(ctx, req)
+	case ServerEndpointChat:
+		return b.chat(ctx, req)
+	default:
+		return nil, i18n.New(i18n.AIUnsupportedServerEndpoint, b.endpoint)
+	}
+}
+
+// completionRequest 是/completion接口的请求体
+type completionRequest struct {
+	Prompt      string                 `json:"prompt"`
+	NPredict    int                    `json:"n_predict"`
+	Temperature float64                `json:"temperature"`
+	TopP        float64                `json:"top_p"`
+	TopK        int                    `json:"top_k"`
+	MinP        float64                `json:"min_p"`
+	Seed        int                    `json:"seed"`
+	Stop        []string               `json:"stop,omitempty"`
+	CachePrompt bool                   `json:"cache_prompt"`
+	Grammar     string                 `json:"grammar,omitempty"`
+	JSONSchema  map[string]interface{} `json:"json_schema,omitempty"`
+	Stream      bool                   `json:"stream,omitempty"`
+}
+
+// completionResponse 是/completion接口的响应体，流式响应中的每一块也是这个格式
+type completionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+}
+
+// complete 调用/completion接口
+func (b *LlamaServerBackend) complete(ctx context.Context, req *Request) (*Response, error) {
+	opts := req.Options
+	body := completionRequest{
+		Prompt:      b.RenderPrompt(req),
+		NPredict:    opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MinP:        opts.MinP,
+		Seed:        opts.Seed,
+		Stop:        b.template.Stop,
+		CachePrompt: true,
+		Grammar:     opts.Grammar,
+	}
+	if opts.Grammar == "" {
+		body.JSONSchema = opts.JSONSchema
+	}
+
+	if req.Stream != nil {
+		return b.streamComplete(ctx, body, req.Stream)
+	}
+
+	var resp completionResponse
+	if err := postJSON(ctx, b.httpClient, b.serverURL+"/completion", nil, body, &resp); err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Text: resp.Content,
+		Usage: Usage{
+			PromptTokens:     resp.TokensEvaluated,
+			CompletionTokens: resp.TokensPredicted,
+		},
+	}, nil
+}
+
+// streamComplete 以流式方式调用/completion接口，出错时返回已经生成的部分
+func (b *LlamaServerBackend) streamComplete(ctx context.Context, body completionRequest, stream func(string)) (*Response, error) {
+	body.Stream = true
+
+	var text strings.Builder
+	var usage Usage
+	err := postStream(ctx, b.httpClient, b.serverURL+"/completion", nil, body, func(line string) (bool, error) {
+		data, ok := sseData(line)
+		if !ok {
+			return false, nil
+		}
+		var chunk completionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, i18n.Wrap(err, i18n.AIParseStream)
+		}
+		if chunk.Content != "" {
+			text.WriteString(chunk.Content)
+			stream(chunk.Content)
+		}
+		if chunk.Stop {
+			usage = Usage{PromptTokens: chunk.TokensEvaluated, CompletionTokens: chunk.TokensPredicted}
+		}
+		return chunk.Stop, nil
+	})
+	return &Response{Text: text.String(), Usage: usage}, err
+}
+
+// chatMessage 是chat completions接口中的一条消息
+type chatMessage struct {
+	Role             string `json:"role"`
+	Content          string `json:"content"`
+	ReasoningContent string `json:"reasoning_content,omitempty"` // llama-server和vLLM等单独返回的推理内容
+	Thinking         string `json:"thinking,omitempty"`          // Ollama单独返回的推理内容
+}
+
+// chatCompletionRequest 是/v1/chat/completions接口的请求体
+type chatCompletionRequest struct {
+	Model          string          `json:"model,omitempty"`
+	Messages       []chatMessage   `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Temperature    float64         `json:"temperature"`
+	TopP           float64         `json:"top_p,omitempty"`
+	TopK           int             `json:"top_k,omitempty"`
+	MinP           float64         `json:"min_p,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	Grammar        string          `json:"grammar,omitempty"` // llama-server扩展字段
+	// llama-server扩展字段，传给模型的Jinja对话模板，例如Qwen3的enable_thinking
+	ChatTemplateKwargs map[string]interface{} `json:"chat_template_kwargs,omitempty"`
+	Stream             bool                   `json:"stream,omitempty"`
+	StreamOptions      *streamOptions         `json:"stream_options,omitempty"`
+}
+
+// streamOptions 是chat completions接口的流式参数
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// responseFormat 指定chat completions接口的返回格式
+type responseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *jsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// jsonSchemaFormat 是response_format中json_schema类型的参数
+type jsonSchemaFormat struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict"`
+}
+
+// schemaResponseFormat 返回要求按JSON Schema输出的response_format
+func schemaResponseFormat(schema map[string]interface{}) *responseFormat {
+	return &responseFormat{
+		Type: "json_schema",
+		JSONSchema: &jsonSchemaFormat{
+			Name:   "commit_message",
+			Schema: schema,
+			Strict: true,
+		},
+	}
+}
+
+// chatCompletionResponse 是/v1/chat/completions接口的响应体
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// chatMessages 把请求转换为chat消息列表
+func chatMessages(req *Request) []chatMessage {
+	messages := []chatMessage{}
+	if req.System != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: req.System})
+	}
+	return append(messages, chatMessage{Role: "user", Content: req.Prompt})
+}
+
+// toResponse 从chat completions响应中提取结果
+func (r *chatCompletionResponse) toResponse() (*Response, error) {
+	if len(r.Choices) == 0 {
+		return nil, i18n.New(i18n.AINoChoices)
+	}
+	return &Response{
+		Text:      r.Choices[0].Message.Content,
+		Reasoning: r.Choices[0].Message.ReasoningContent,
+		Usage: Usage{
+			PromptTokens:     r.Usage.PromptTokens,
+			CompletionTokens: r.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+// chat 调用/v1/chat/completions接口
+func (b *LlamaServerBackend) chat(ctx context.Context, req *Request) (*Response, error) {
+	opts := req.Options
+	body := chatCompletionRequest{
+		Messages:    chatMessages(req),
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MinP:        opts.MinP,
+		Seed:        seedPtr(opts.Seed),
+		Grammar:     opts.Grammar,
+		// 由服务端的Jinja模板决定是否推理，代替/no_think和/think
+		ChatTemplateKwargs: map[string]interface{}{"enable_thinking": opts.Think},
+	}
+	if opts.Grammar == "" && opts.JSONSchema != nil {
+		body.ResponseFormat = schemaResponseFormat(opts.JSONSchema)
+	}
+
+	if req.Stream != nil {
+		return streamChat(ctx, b.httpClient, b.serverURL+"/v1/chat/completions", nil, body, req.Stream)
+	}
+
+	var resp chatCompletionResponse
+	if err := postJSON(ctx, b.httpClient, b.serverURL+"/v1/chat/completions", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toResponse()
+}
+
+// healthy 检查llama-server是否已就绪（模型加载中时/health返回503）
+func (b *LlamaServerBackend) healthy(ctx context.Context) bool {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, b.serverURL+"/health", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// ensureRunning 确保llama-server可用，必要时启动它并等待模型加载完成
+func (b *LlamaServerBackend) ensureRunning(ctx context.Context) error {
+	if b.healthy(ctx) {
+		return nil
+	}
+	if !b.spawn {
+		return i18n.New(i18n.AIServerNotRunning, b.serverURL)
+	}
+
+	u, err := url.Parse(b.serverURL)
+	if err != nil {
+		return i18n.Wrap(err, i18n.AIParseServerURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "8080"
+	}
+
+	// 服务可能已经启动但仍在加载模型，端口被占用时不再重复启动
+	if conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), time.Second); err == nil {
+		conn.Close()
+	} else {
+		cmd := exec.Command(
+			b.llamaCppPath+"/llama-server",
+			"-m", b.modelPath,
+			"--host", u.Hostname(),
+			"--port", port,
+		)
+		if b.contextSize > 0 {
+			cmd.Args = append(cmd.Args, "--ctx-size", fmt.Sprintf("%d", b.contextSize))
+		}
+		if b.threads > 0 {
+			cmd.Args = append(cmd.Args, "--threads", fmt.Sprintf("%d", b.threads))
+		}
+		parallel := b.parallel
+		if parallel <= 0 {
+			parallel = DefaultParallel
+		}
+		cmd.Args = append(cmd.Args, "--parallel", fmt.Sprintf("%d", parallel))
+		cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+b.llamaCppPath)
+		detachProcess(cmd)
+		if b.debug {
+			fmt.Println(i18n.T(i18n.AIDebugStartServer, cmd.String()))
+		}
+		if err := cmd.Start(); err != nil {
+			return i18n.Wrap(err, i18n.AIStartServer)
+		}
+		// 不等待子进程，让它在后台常驻
+		cmd.Process.Release()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return i18n.Wrap(ctx.Err(), i18n.AIServerReadyTimeout)
+		case <-ticker.C:
+			if b.healthy(ctx) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// testOptions 是测试请求使用的生成参数
+var testOptions = Options{
+	Temperature: 0.3,
+	MaxTokens:   256,
+	TopP:        0.9,
+	TopK:        40,
+	MinP:        0.05,
+	Seed:        42,
+}
+
+// fakeServer 是假的推理服务，记录收到的请求体，按路径返回预设的响应
+type fakeServer struct {
+	*httptest.Server
+	bodies map[string]map[string]interface{} // 每个路径最后一次收到的请求体
+	header http.Header                       // 最后一次请求的请求头
+}
+
+// newFakeServer 创建假的推理服务，handle返回路径对应的状态码和响应体
+func newFakeServer(t *testing.T, handle func(path string, body map[string]interface{}) (int, string)) *fakeServer {
+	s := &fakeServer{bodies: map[string]map[string]interface{}{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if r.Method == http.MethodPost {
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, &body); err != nil {
+				t.Errorf("%s: invalid request body: %v", r.URL.Path, err)
+			}
+			s.bodies[r.URL.Path] = body
+			s.header = r.Header.Clone()
+		}
+		status, resp := handle(r.URL.Path, body)
+		w.WriteHeader(status)
+		io.WriteString(w, resp)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// hasCode 判断错误链中是否有编号为code的错误
+func hasCode(err error, code i18n.Code) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*i18n.Error); ok && e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFields 检查请求体中的字段
+func checkFields(t *testing.T, body map[string]interface{}, want map[string]interface{}) {
+	t.Helper()
+	for key, value := range want {
+		got, ok := body[key]
+		if value == nil {
+			if ok {
+				t.Errorf("%s: want absent, got %v", key, got)
+			}
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(value) {
+			t.Errorf("%s: got %v, want %v", key, got, value)
+		}
+	}
+}
+
+func TestLlamaServerComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want map[string]interface{}
+	}{
+		{
+			name: "grammar",
+			opts: Options{Grammar: `root ::= "{}"`, JSONSchema: map[string]interface{}{"type": "object"}},
+			want: map[string]interface{}{"grammar": `root ::= "{}"`, "json_schema": nil},
+		},
+		{
+			name: "json schema",
+			opts: Options{JSONSchema: map[string]interface{}{"type": "object"}},
+			want: map[string]interface{}{"grammar": nil, "json_schema": map[string]interface{}{"type": "object"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+				if path == "/health" {
+					return http.StatusOK, `{"status": "ok"}`
+				}
+				return http.StatusOK, `{"content": "{}", "stop": true, "tokens_predicted": 3, "tokens_evaluated": 20}`
+			})
+			backend := NewLlamaServerBackend(server.URL, ServerEndpointCompletion, false)
+
+			opts := testOptions
+			opts.Grammar, opts.JSONSchema = tt.opts.Grammar, tt.opts.JSONSchema
+			resp, err := backend.Generate(context.Background(), &Request{System: "system", Prompt: "prompt", Options: opts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "{}" || resp.Usage.PromptTokens != 20 || resp.Usage.CompletionTokens != 3 {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+
+			body := server.bodies["/completion"]
+			checkFields(t, body, map[string]interface{}{
+				"n_predict":    256,
+				"temperature":  0.3,
+				"top_p":        0.9,
+				"top_k":        40,
+				"min_p":        0.05,
+				"seed":         42,
+				"cache_prompt": true,
+				"stream":       nil,
+			})
+			checkFields(t, body, tt.want)
+			if prompt, _ := body["prompt"].(string); !strings.Contains(prompt, "system") || !strings.Contains(prompt, "prompt") {
+				t.Errorf("prompt is not rendered with the chat template: %q", prompt)
+			}
+		})
+	}
+}
+
+func TestLlamaServerCompleteStream(t *testing.T) {
+	server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+		if path == "/health" {
+			return http.StatusOK, `{"status": "ok"}`
+		}
+		return http.StatusOK, "data: {\"content\": \"{\\\"type\\\"\"}\n\n" +
+			"data: {\"content\": \": \\\"fix\\\"}\"}\n\n" +
+			"data: {\"content\": \"\", \"stop\": true, \"tokens_predicted\": 5, \"tokens_evaluated\": 30}\n\n"
+	})
+	backend := NewLlamaServerBackend(server.URL, ServerEndpointCompletion, false)
+
+	var streamed strings.Builder
+	req := &Request{Prompt: "prompt", Options: testOptions, Stream: func(text string) { streamed.WriteString(text) }}
+	resp, err := backend.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type": "fix"}`; resp.Text != want || streamed.String() != want {
+		t.Errorf("got text %q, streamed %q, want %q", resp.Text, streamed.String(), want)
+	}
+	if resp.Usage.CompletionTokens != 5 || resp.Usage.PromptTokens != 30 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	checkFields(t, server.bodies["/completion"], map[string]interface{}{"stream": true})
+}
+
+func TestLlamaServerChat(t *testing.T) {
+	server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+		if path == "/health" {
+			return http.StatusOK, `{"status": "ok"}`
+		}
+		return http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "{}"}}], "usage": {"prompt_tokens": 12, "completion_tokens": 2}}`
+	})
+	backend := NewLlamaServerBackend(server.URL, ServerEndpointChat, false)
+
+	opts := testOptions
+	opts.JSONSchema = map[string]interface{}{"type": "object"}
+	resp, err := backend.Generate(context.Background(), &Request{System: "system", Prompt: "prompt", Options: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "{}" || resp.Usage.PromptTokens != 12 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	body := server.bodies["/v1/chat/completions"]
+	checkFields(t, body, map[string]interface{}{
+		"max_tokens":  256,
+		"temperature": 0.3,
+		"top_k":       40,
+		"seed":        42,
+		"grammar":     nil,
+		"messages":    []interface{}{map[string]interface{}{"role": "system", "content": "system"}, map[string]interface{}{"role": "user", "content": "prompt"}},
+		"response_format": map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": map[string]interface{}{"name": "commit_message", "schema": map[string]interface{}{"type": "object"}, "strict": true},
+		},
+	})
+}
+
+func TestLlamaServerErrors(t *testing.T) {
+	t.Run("http status", func(t *testing.T) {
+		server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+			if path == "/health" {
+				return http.StatusOK, `{"status": "ok"}`
+			}
+			return http.StatusServiceUnavailable, "no slot available\n"
+		})
+		for _, endpoint := range []string{ServerEndpointCompletion, ServerEndpointChat} {
+			backend := NewLlamaServerBackend(server.URL, endpoint, false)
+			_, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: testOptions})
+			if !hasCode(err, i18n.AIHTTPStatus) || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "no slot available") {
+				t.Errorf("%s: want HTTP status error with response body, got %v", endpoint, err)
+			}
+		}
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+			return http.StatusServiceUnavailable, `{"error": "loading model"}`
+		})
+		backend := NewLlamaServerBackend(server.URL, ServerEndpointCompletion, false)
+		_, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: testOptions})
+		if !hasCode(err, i18n.AIServerNotRunning) {
+			t.Errorf("want %s, got %v", i18n.AIServerNotRunning, err)
+		}
+		if len(server.bodies) != 0 {
+			t.Errorf("request sent to a server that is not ready: %v", server.bodies)
+		}
+	})
+
+	t.Run("no choices", func(t *testing.T) {
+		server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+			return http.StatusOK, `{"choices": []}`
+		})
+		backend := NewLlamaServerBackend(server.URL, ServerEndpointChat, false)
+		_, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: testOptions})
+		if !hasCode(err, i18n.AINoChoices) {
+			t.Errorf("want %s, got %v", i18n.AINoChoices, err)
+		}
+	})
+}
+
+func TestLlamaServerMaxConcurrency(t *testing.T) {
+	server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+		if path == "/props" {
+			return http.StatusOK, `{"total_slots": 2, "default_generation_settings": {"n_ctx": 4096}}`
+		}
+		return http.StatusNotFound, ""
+	})
+	backend := NewLlamaServerBackend(server.URL, ServerEndpointCompletion, false)
+	if got := backend.MaxConcurrency(); got != 2 {
+		t.Errorf("got %d, want the slot count 2", got)
+	}
+
+	backend = NewLlamaServerBackend(server.URL, ServerEndpointCompletion, false)
+	backend.SetParallel(3)
+	if got := backend.MaxConcurrency(); got != 3 {
+		t.Errorf("got %d, want the configured 3", got)
+	}
+
+	// 服务未运行时使用自动启动的slot数
+	backend = NewLlamaServerBackend(closedURL(t), ServerEndpointCompletion, false)
+	if got := backend.MaxConcurrency(); got != DefaultParallel {
+		t.Errorf("got %d, want DefaultParallel", got)
+	}
+}
+
+// closedURL 返回一个没有服务监听的地址
+func closedURL(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return "http://" + addr
+}
+
+func TestLlamaServerSpawnParallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	for _, tt := range []struct {
+		parallel int
+		want     string
+	}{
+		{parallel: 0, want: "--parallel 4"},
+		{parallel: 2, want: "--parallel 2"},
+	} {
+		// 假的llama-server只记录启动参数，不监听端口
+		dir := t.TempDir()
+		argsFile := filepath.Join(dir, "args")
+		script := "#!/bin/sh\necho \"$@\" > " + argsFile + ".tmp && mv " + argsFile + ".tmp " + argsFile + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "llama-server"), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		backend := NewLlamaServerBackend(closedURL(t), ServerEndpointCompletion, false)
+		backend.EnableSpawn(dir, "model.gguf", 0)
+		backend.SetParallel(tt.parallel)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := backend.ensureRunning(ctx)
+		cancel()
+		if !hasCode(err, i18n.AIServerReadyTimeout) {
+			t.Fatalf("want %s, got %v", i18n.AIServerReadyTimeout, err)
+		}
+
+		var args []byte
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if args, err = os.ReadFile(argsFile); err == nil {
+				break
+			}
+		}
+		if !strings.Contains(string(args), tt.want) {
+			t.Errorf("parallel %d: llama-server started with %q, want %s", tt.parallel, args, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// 分段总结模式
+const (
+	MapReduceOff    = "off"    // 不分段总结
+	MapReduceAuto   = "auto"   // diff超出token预算时分段总结
+	MapReduceAlways = "always" // 总是分段总结
+)
+
+// summaryMaxTokens 是单个文件摘要的最大token数
+const summaryMaxTokens = 256
+
+// commitPrompt 返回生成CommitMessage的提示信息，按分段总结模式决定是否先逐个文件总结；
+// 只显示prompt时不调用模型，总是使用直接打包diff的提示信息
+func (c *Client) commitPrompt(ctx context.Context, diffInfo *git.DiffInfo, onlyPrompt bool) (string, error) {
+	if onlyPrompt || !c.useMapReduce(ctx, diffInfo) {
+		return c.buildPrompt(ctx, diffInfo)
+	}
+	return c.buildMapReducePrompt(ctx, diffInfo)
+}
+
+// useMapReduce 判断是否需要分段总结
+func (c *Client) useMapReduce(ctx context.Context, diffInfo *git.DiffInfo) bool {
+	switch c.mapReduce {
+	case MapReduceAlways:
+		return true
+	case MapReduceAuto:
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		counter := c.newCounter(ctx, diffInfo.RawDiff)
+		budget, err := c.diffBudget(diffInfo, counter)
+		// 提示词模板有错误时不分段总结，由buildPrompt报告错误
+		return err == nil && counter.count(diffInfo.RawDiff) > budget
+	default:
+		return false
+	}
+}
+
+// fileSummary 表示单个文件的变更摘要
+type fileSummary struct {
+	name    string
+	summary string
+	err     error
+}
+
+// buildMapReducePrompt 先并发地逐个文件总结变更（map），再把摘要合并为生成CommitMessage的提示信息（reduce）
+func (c *Client) buildMapReducePrompt(ctx context.Context, diffInfo *git.DiffInfo) (string, error) {
+	backend, err := c.getBackend()
+	if err != nil {
+		return "", err
+	}
+
+	fileDiffs := splitDiffByFile(diffInfo.RawDiff)
+	summaries := make([]fileSummary, len(fileDiffs))
+
+	// 用带缓冲的channel限制并发数
+	sem := make(chan struct{}, maxConcurrency(backend))
+	var wg sync.WaitGroup
+	for i, fileDiff := range fileDiffs {
+		wg.Add(1)
+		go func(i int, fileDiff string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			name := extractFileName(fileDiff)
+			summary, err := c.summarizeFile(ctx, fileDiff, i == 0)
+			summaries[i] = fileSummary{name: name, summary: summary, err: err}
+			if c.debug {
+				fmt.Println(i18n.T(i18n.AIDebugFileSummary, name, summary))
+			}
+		}(i, fileDiff)
+	}
+	wg.Wait()
+
+	for _, s := range summaries {
+		if s.err != nil {
+			return "", i18n.Wrap(s.err, i18n.AISummarizeFile, s.name)
+		}
+	}
+
+	return c.renderSummaryPrompt(diffInfo, summaries)
+}
+
+// summarizeFile 总结单个文件的变更
+func (c *Client) summarizeFile(ctx context.Context, fileDiff string, showPrompt bool) (string, error) {
+	countCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	counter := c.newCounter(countCtx, fileDiff)
+
+	p := c.prompts()
+	header := p.fileSummary
+	budget := c.promptBudget() + c.generationTokens() - summaryMaxTokens - counter.count(p.system) - counter.count(header)
+	prompt := header + packDiff(p, fileDiff, budget, counter.count)
+
+	req, err := c.newRequest(prompt, 0)
+	if err != nil {
+		return "", err
+	}
+	// 摘要是自由文本，不使用JSON约束
+	req.Options.Grammar = ""
+	req.Options.JSONSchema = nil
+	req.Options.FreeForm = true
+	// 摘要很短，不需要推理
+	req.Options.Think = false
+	req.Options.MaxTokens = summaryMaxTokens
+
+	summary, err := c.send(ctx, req, showPrompt, false)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// buildSummaryPrompt 用各文件的变更摘要构建生成CommitMessage的提示信息
+func buildSummaryPrompt(p *promptLang, diffInfo *git.DiffInfo, examples []string, rules *lint.Rules, summaries []fileSummary) string {
+	var sb strings.Builder
+
+	sb.WriteString(p.summaryTask)
+
+	sb.WriteString(p.files)
+	for i, file := range diffInfo.Files {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, file))
+	}
+
+	sb.WriteString(fmt.Sprintf(p.additions, diffInfo.Additions))
+	sb.WriteString(fmt.Sprintf(p.deletions, diffInfo.Deletions))
+
+	sb.WriteString(p.summaries)
+	for i, s := range summaries {
+		sb.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, s.name, s.summary))
+	}
+
+	writeExamples(&sb, p, examples)
+	writeResponseInstructions(&sb, p, rules)
+
+	return sb.String()
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// mapReduceFiles 是分段总结测试中修改的文件
+var mapReduceFiles = []string{"alpha.go", "beta.go", "gamma.go"}
+
+// mapReduceDiff 返回修改了mapReduceFiles的diff
+func mapReduceDiff() *git.DiffInfo {
+	var rawDiff strings.Builder
+	for _, name := range mapReduceFiles {
+		rawDiff.WriteString(fileDiff(name, "changed line", 3))
+	}
+	return &git.DiffInfo{Files: mapReduceFiles, RawDiff: rawDiff.String(), Additions: 9}
+}
+
+// summarizingReply 对总结请求回复提示词中文件的摘要，failFile的总结请求返回错误，对生成请求回复commit message
+func summarizingReply(failFile string) func(req *Request, n int) (*Response, error) {
+	return func(req *Request, n int) (*Response, error) {
+		if !req.Options.FreeForm {
+			return &Response{Text: `{"type": "feat", "scope": "", "subject": "summarize files", "body": "", "breaking_changes": false}`}, nil
+		}
+		for _, name := range mapReduceFiles {
+			if strings.Contains(req.Prompt, "diff --git a/"+name) {
+				if name == failFile {
+					return nil, errors.New("model crashed")
+				}
+				return &Response{Text: fmt.Sprintf("  summary of %s\n", name)}, nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected prompt:\n%s", req.Prompt)
+	}
+}
+
+func TestMapReduce(t *testing.T) {
+	backend := &scriptedBackend{reply: summarizingReply("")}
+	c := newScriptedClient(backend)
+	c.SetMapReduce(MapReduceAlways)
+
+	commitMsg, err := c.GenerateCommitMessage(context.Background(), mapReduceDiff(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitMsg.Subject != "summarize files" {
+		t.Errorf("got %+v", commitMsg)
+	}
+
+	// 每个文件一次总结请求，最后一次生成请求
+	if len(backend.requests) != len(mapReduceFiles)+1 {
+		t.Fatalf("got %d requests, want %d", len(backend.requests), len(mapReduceFiles)+1)
+	}
+	summarized := map[string]int{}
+	for _, req := range backend.requests[:len(mapReduceFiles)] {
+		if !req.Options.FreeForm || req.Options.Grammar != "" || req.Options.JSONSchema != nil || req.Options.MaxTokens != summaryMaxTokens {
+			t.Errorf("summary request should be free-form: %+v", req.Options)
+		}
+		for _, name := range mapReduceFiles {
+			if strings.Contains(req.Prompt, "diff --git a/"+name) {
+				summarized[name]++
+			}
+		}
+	}
+	for _, name := range mapReduceFiles {
+		if summarized[name] != 1 {
+			t.Errorf("%s summarized %d times", name, summarized[name])
+		}
+	}
+
+	reduce := backend.requests[len(mapReduceFiles)]
+	if reduce.Options.FreeForm || strings.Contains(reduce.Prompt, "diff --git") {
+		t.Errorf("reduce request should use the summaries instead of the diff:\n%s", reduce.Prompt)
+	}
+	p := c.prompts()
+	for i, name := range mapReduceFiles {
+		if want := fmt.Sprintf("%d. %s: summary of %s\n", i+1, name, name); !strings.Contains(reduce.Prompt, want) {
+			t.Errorf("reduce prompt does not contain %q:\n%s", want, reduce.Prompt)
+		}
+	}
+	if !strings.HasPrefix(reduce.Prompt, p.summaryTask) || !strings.Contains(reduce.Prompt, p.summaries) {
+		t.Errorf("unexpected reduce prompt:\n%s", reduce.Prompt)
+	}
+}
+
+func TestMapReduceSummarizeError(t *testing.T) {
+	backend := &scriptedBackend{reply: summarizingReply("beta.go")}
+	c := newScriptedClient(backend)
+	c.SetMapReduce(MapReduceAlways)
+
+	_, err := c.buildMapReducePrompt(context.Background(), mapReduceDiff())
+	if i18n.CodeOf(err) != i18n.AISummarizeFile || !strings.Contains(err.Error(), "beta.go") {
+		t.Errorf("want %s for beta.go, got %v", i18n.AISummarizeFile, err)
+	}
+
+	// 生成时也报告总结失败，不发送生成请求
+	backend = &scriptedBackend{reply: summarizingReply("beta.go")}
+	c = newScriptedClient(backend)
+	c.SetMapReduce(MapReduceAlways)
+	if _, err := c.GenerateCommitMessage(context.Background(), mapReduceDiff(), false); !hasCode(err, i18n.AISummarizeFile) {
+		t.Errorf("want %s, got %v", i18n.AISummarizeFile, err)
+	}
+	for _, req := range backend.requests {
+		if !req.Options.FreeForm {
+			t.Error("commit message should not be generated after a failed summary")
+		}
+	}
+}
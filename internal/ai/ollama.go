@@ -0,0 +1,242 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// Ollama支持的接口
+const (
+	OllamaEndpointGenerate = "generate" // /api/generate接口
+	OllamaEndpointChat     = "chat"     // /api/chat接口
+)
+
+// DefaultOllamaURL 是Ollama的默认地址
+const DefaultOllamaURL = "http://127.0.0.1:11434"
+
+// DefaultOllamaModel 是默认使用的Ollama模型标签
+const DefaultOllamaModel = "qwen3:1.7b"
+
+// OllamaBackend 通过Ollama的HTTP接口生成回复，直接使用已经pull到本地的模型
+type OllamaBackend struct {
+	baseURL    string       // Ollama地址
+	model      string       // 模型标签，例如 qwen3:1.7b
+	endpoint   string       // 使用的接口（generate或chat）
+	jsonMode   bool         // 是否使用format: json模式
+	numCtx     int          // 上下文长度，为0时使用Ollama的默认值
+	numThread  int          // 推理线程数，为0时使用Ollama的默认值
+	parallel   int          // 同时发送的请求数，为0时使用DefaultParallel
+	httpClient *http.Client // HTTP客户端
+}
+
+// NewOllamaBackend 创建一个新的Ollama后端
+func NewOllamaBackend(baseURL, model, endpoint string) *OllamaBackend {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	// 兼容OLLAMA_HOST中不带协议的写法，例如 127.0.0.1:11434
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	if endpoint == "" {
+		endpoint = OllamaEndpointGenerate
+	}
+	return &OllamaBackend{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		endpoint:   endpoint,
+		httpClient: &http.Client{},
+	}
+}
+
+// SetJSONMode 设置是否使用format: json模式
+func (b *OllamaBackend) SetJSONMode(enabled bool) {
+	b.jsonMode = enabled
+}
+
+// SetContextSize 设置上下文长度（num_ctx），为0时使用Ollama的默认值
+func (b *OllamaBackend) SetContextSize(size int) {
+	b.numCtx = size
+}
+
+// SetThreads 设置推理线程数（num_thread），为0时使用Ollama的默认值
+func (b *OllamaBackend) SetThreads(threads int) {
+	b.numThread = threads
+}
+
+// Name 返回后端名称
+func (b *OllamaBackend) Name() string {
+	return BackendOllama
+}
+
+// SetParallel 设置同时发送的请求数，应与服务端的OLLAMA_NUM_PARALLEL一致，为0时使用DefaultParallel
+func (b *OllamaBackend) SetParallel(parallel int) {
+	b.parallel = parallel
+}
+
+// MaxConcurrency Ollama可以同时处理多个请求（受OLLAMA_NUM_PARALLEL限制）
+func (b *OllamaBackend) MaxConcurrency() int {
+	if b.parallel > 0 {
+		return b.parallel
+	}
+	return DefaultParallel
+}
+
+// ollamaOptions 是Ollama的生成参数，采样参数总是发送，为0时也不使用模型Modelfile中的默认值
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	TopP        float64 `json:"top_p"`
+	TopK        int     `json:"top_k"`
+	MinP        float64 `json:"min_p"`
+	Seed        *int    `json:"seed,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	NumThread   int     `json:"num_thread,omitempty"`
+}
+
+// ollamaGenerateRequest 是/api/generate接口的请求体
+type ollamaGenerateRequest struct {
+	Model   string        `json:"model"`
+	System  string        `json:"system,omitempty"`
+	Prompt  string        `json:"prompt"`
+	Format  interface{}   `json:"format,omitempty"`
+	Stream  bool          `json:"stream"`
+	Think   *bool         `json:"think,omitempty"` // 推理内容在thinking字段中单独返回，false时关闭思考模型的推理
+	Options ollamaOptions `json:"options"`
+}
+
+// ollamaChatRequest 是/api/chat接口的请求体
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Format   interface{}   `json:"format,omitempty"`
+	Stream   bool          `json:"stream"`
+	Think    *bool         `json:"think,omitempty"`
+	Options  ollamaOptions `json:"options"`
+}
+
+// ollamaResponse 是/api/generate和/api/chat接口的响应体
+type ollamaResponse struct {
+	Response        string      `json:"response"` // /api/generate的结果
+	Thinking        string      `json:"thinking"` // /api/generate的推理内容
+	Message         chatMessage `json:"message"`  // /api/chat的结果
+	Done            bool        `json:"done"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+}
+
+// Generate 调用Ollama生成回复
+func (b *OllamaBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	opts := req.Options
+	options := ollamaOptions{
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MinP:        opts.MinP,
+		Seed:        seedPtr(opts.Seed),
+		NumPredict:  opts.MaxTokens,
+		NumCtx:      b.numCtx,
+		NumThread:   b.numThread,
+	}
+	// format可以是"json"，也可以是一个JSON Schema（Ollama不支持GBNF语法）
+	var format interface{}
+	if opts.JSONSchema != nil {
+		format = opts.JSONSchema
+	} else if b.jsonMode && !opts.FreeForm {
+		format = "json"
+	}
+
+	var url string
+	var body interface{}
+	switch b.endpoint {
+	case OllamaEndpointGenerate:
+		url = b.baseURL + "/api/generate"
+		body = ollamaGenerateRequest{
+			Model:   b.model,
+			System:  req.System,
+			Prompt:  req.Prompt,
+			Format:  format,
+			Stream:  req.Stream != nil,
+			Think:   &opts.Think,
+			Options: options,
+		}
+	case OllamaEndpointChat:
+		url = b.baseURL + "/api/chat"
+		body = ollamaChatRequest{
+			Model:    b.model,
+			Messages: chatMessages(req),
+			Format:   format,
+			Stream:   req.Stream != nil,
+			Think:    &opts.Think,
+			Options:  options,
+		}
+	default:
+		return nil, i18n.New(i18n.AIUnsupportedOllamaEndpoint, b.endpoint)
+	}
+
+	if req.Stream != nil {
+		return b.stream(ctx, url, body, req.Stream)
+	}
+
+	var resp ollamaResponse
+	if err := postJSON(ctx, b.httpClient, url, nil, body, &resp); err != nil {
+		return nil, i18n.Wrap(err, i18n.AIOllamaFailed)
+	}
+
+	return &Response{
+		Text:      resp.text(),
+		Reasoning: resp.reasoning(),
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}, nil
+}
+
+// text 返回/api/generate或/api/chat的生成内容
+func (r *ollamaResponse) text() string {
+	if r.Response != "" {
+		return r.Response
+	}
+	return r.Message.Content
+}
+
+// reasoning 返回/api/generate或/api/chat单独返回的推理内容
+func (r *ollamaResponse) reasoning() string {
+	if r.Thinking != "" {
+		return r.Thinking
+	}
+	return r.Message.Thinking
+}
+
+// stream 以流式方式调用Ollama，响应是每行一个JSON对象，出错时返回已经生成的部分
+func (b *OllamaBackend) stream(ctx context.Context, url string, body interface{}, stream func(string)) (*Response, error) {
+	var text, reasoning strings.Builder
+	var usage Usage
+	err := postStream(ctx, b.httpClient, url, nil, body, func(line string) (bool, error) {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return false, i18n.Wrap(err, i18n.AIParseStream)
+		}
+		reasoning.WriteString(chunk.reasoning())
+		if t := chunk.text(); t != "" {
+			text.WriteString(t)
+			stream(t)
+		}
+		if chunk.Done {
+			usage = Usage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount}
+		}
+		return chunk.Done, nil
+	})
+	if err != nil {
+		err = i18n.Wrap(err, i18n.AIOllamaFailed)
+	}
+	return &Response{Text: text.String(), Reasoning: reasoning.String(), Usage: usage}, err
+}
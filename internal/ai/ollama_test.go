@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// ollamaReply 是假的Ollama服务对/api/generate和/api/chat的回复
+const ollamaReply = `{"response": "{}", "message": {"role": "assistant", "content": "{}"}, "done": true}`
+
+func TestOllamaOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want map[string]interface{}
+	}{
+		{
+			name: "all options",
+			opts: testOptions,
+			want: map[string]interface{}{"temperature": 0.3, "top_p": 0.9, "top_k": 40, "min_p": 0.05, "seed": 42, "num_predict": 256, "num_ctx": 4096, "num_thread": 4},
+		},
+		{
+			// 为0的采样参数也要发送，否则使用Modelfile中的默认值；随机种子不发送
+			name: "zero sampling options",
+			opts: Options{Seed: -1},
+			want: map[string]interface{}{"temperature": 0, "top_p": 0, "top_k": 0, "min_p": 0, "seed": nil, "num_predict": nil},
+		},
+	}
+	for _, tt := range tests {
+		for _, endpoint := range []string{OllamaEndpointGenerate, OllamaEndpointChat} {
+			t.Run(tt.name+"/"+endpoint, func(t *testing.T) {
+				server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+					return http.StatusOK, ollamaReply
+				})
+				backend := NewOllamaBackend(server.URL, "qwen3:1.7b", endpoint)
+				backend.SetContextSize(4096)
+				backend.SetThreads(4)
+				if _, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: tt.opts}); err != nil {
+					t.Fatal(err)
+				}
+				body := server.bodies["/api/"+endpoint]
+				checkFields(t, body, map[string]interface{}{"model": "qwen3:1.7b", "stream": false})
+				options, _ := body["options"].(map[string]interface{})
+				checkFields(t, options, tt.want)
+			})
+		}
+	}
+}
+
+func TestOllamaFormat(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "required": []interface{}{"type"}}
+	tests := []struct {
+		name     string
+		jsonMode bool
+		opts     Options
+		want     interface{} // nil表示不发送format
+	}{
+		{name: "json schema", jsonMode: true, opts: Options{JSONSchema: schema}, want: schema},
+		{name: "json schema without json mode", opts: Options{JSONSchema: schema}, want: schema},
+		{name: "json mode", jsonMode: true, want: "json"},
+		{name: "free form", jsonMode: true, opts: Options{FreeForm: true}},
+		{name: "off"},
+	}
+	for _, tt := range tests {
+		for _, endpoint := range []string{OllamaEndpointGenerate, OllamaEndpointChat} {
+			t.Run(tt.name+"/"+endpoint, func(t *testing.T) {
+				server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+					return http.StatusOK, ollamaReply
+				})
+				backend := NewOllamaBackend(server.URL, "", endpoint)
+				backend.SetJSONMode(tt.jsonMode)
+				if _, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: tt.opts}); err != nil {
+					t.Fatal(err)
+				}
+				got, ok := server.bodies["/api/"+endpoint]["format"]
+				if tt.want == nil {
+					if ok {
+						t.Errorf("want no format, got %v", got)
+					}
+				} else if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got format %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestOllamaThink(t *testing.T) {
+	for _, endpoint := range []string{OllamaEndpointGenerate, OllamaEndpointChat} {
+		for _, think := range []bool{false, true} {
+			server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+				return http.StatusOK, ollamaReply
+			})
+			backend := NewOllamaBackend(server.URL, "qwen3:1.7b", endpoint)
+
+			opts := testOptions
+			opts.Think = think
+			if _, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: opts}); err != nil {
+				t.Fatal(err)
+			}
+			// think为false时也要发送，否则思考模型默认会推理
+			body := server.bodies["/api/"+endpoint]
+			if got, ok := body["think"]; !ok || got != think {
+				t.Errorf("%s: got think %v (sent: %v), want %v", endpoint, got, ok, think)
+			}
+		}
+	}
+}
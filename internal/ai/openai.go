@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// DefaultOpenAIBaseURL 是OpenAI兼容接口的默认地址（vLLM的默认端口）
+const DefaultOpenAIBaseURL = "http://127.0.0.1:8000/v1"
+
+// DefaultOpenAIAPIKeyEnv 是默认读取API Key的环境变量
+const DefaultOpenAIAPIKeyEnv = "OPENAI_API_KEY"
+
+// OpenAIBackend 通过OpenAI兼容的chat completions接口生成回复，适用于vLLM、LocalAI等自建网关
+type OpenAIBackend struct {
+	baseURL    string       // 接口地址，例如 http://127.0.0.1:8000/v1
+	apiKey     string       // API Key，为空时不发送Authorization头
+	model      string       // 模型名称
+	jsonMode   bool         // 是否要求以JSON对象格式返回
+	kwargs     bool         // 是否通过chat_template_kwargs控制思考模式
+	parallel   int          // 同时发送的请求数，为0时使用DefaultParallel
+	httpClient *http.Client // HTTP客户端
+}
+
+// NewOpenAIBackend 创建一个新的OpenAI兼容后端
+func NewOpenAIBackend(baseURL, apiKey, model string, timeout time.Duration) *OpenAIBackend {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAIBackend{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// SetJSONMode 设置是否通过response_format要求返回JSON对象
+func (b *OpenAIBackend) SetJSONMode(enabled bool) {
+	b.jsonMode = enabled
+}
+
+// SetTemplateKwargs 设置是否通过chat_template_kwargs的enable_thinking开启或关闭思考模式，
+// vLLM、SGLang等支持该字段，不接受未知字段的网关需要关闭
+func (b *OpenAIBackend) SetTemplateKwargs(enabled bool) {
+	b.kwargs = enabled
+}
+
+// Name 返回后端名称
+func (b *OpenAIBackend) Name() string {
+	return BackendOpenAI
+}
+
+// SetParallel 设置同时发送的请求数，为0时使用DefaultParallel
+func (b *OpenAIBackend) SetParallel(parallel int) {
+	b.parallel = parallel
+}
+
+// MaxConcurrency 网关可以同时处理多个请求
+func (b *OpenAIBackend) MaxConcurrency() int {
+	if b.parallel > 0 {
+		return b.parallel
+	}
+	return DefaultParallel
+}
+
+// Generate 调用chat completions接口生成回复
+func (b *OpenAIBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	opts := req.Options
+	body := chatCompletionRequest{
+		Model:       b.model,
+		Messages:    chatMessages(req),
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MinP:        opts.MinP,
+		Seed:        seedPtr(opts.Seed),
+	}
+	if b.kwargs {
+		// 未开启--think时显式关闭Qwen3等模型默认开启的推理
+		body.ChatTemplateKwargs = map[string]interface{}{"enable_thinking": opts.Think}
+	}
+	// OpenAI兼容接口不支持GBNF语法，只能使用JSON Schema
+	if opts.JSONSchema != nil {
+		body.ResponseFormat = schemaResponseFormat(opts.JSONSchema)
+	} else if b.jsonMode && !opts.FreeForm {
+		body.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
+	headers := map[string]string{}
+	if b.apiKey != "" {
+		headers["Authorization"] = "Bearer " + b.apiKey
+	}
+
+	if req.Stream != nil {
+		resp, err := streamChat(ctx, b.httpClient, b.baseURL+"/chat/completions", headers, body, req.Stream)
+		if err != nil {
+			return resp, i18n.Wrap(err, i18n.AIOpenAIFailed)
+		}
+		return resp, nil
+	}
+
+	var resp chatCompletionResponse
+	if err := postJSON(ctx, b.httpClient, b.baseURL+"/chat/completions", headers, body, &resp); err != nil {
+		return nil, i18n.Wrap(err, i18n.AIOpenAIFailed)
+	}
+	return resp.toResponse()
+}
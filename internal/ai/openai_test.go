@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+func TestOpenAIGenerate(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	tests := []struct {
+		name       string
+		jsonMode   bool
+		noKwargs   bool
+		opts       Options
+		want       interface{} // 期望的response_format，nil表示不发送
+		wantKwargs interface{} // 期望的chat_template_kwargs，nil表示不发送
+	}{
+		{
+			name: "json schema",
+			opts: Options{JSONSchema: schema, Grammar: `root ::= "{}"`},
+			want: map[string]interface{}{
+				"type":        "json_schema",
+				"json_schema": map[string]interface{}{"name": "commit_message", "schema": schema, "strict": true},
+			},
+			wantKwargs: map[string]interface{}{"enable_thinking": false},
+		},
+		{
+			name:       "json mode with thinking",
+			jsonMode:   true,
+			opts:       Options{Think: true},
+			want:       map[string]interface{}{"type": "json_object"},
+			wantKwargs: map[string]interface{}{"enable_thinking": true},
+		},
+		{
+			name:       "free form",
+			jsonMode:   true,
+			opts:       Options{FreeForm: true},
+			wantKwargs: map[string]interface{}{"enable_thinking": false},
+		},
+		{
+			name:     "no constraint",
+			noKwargs: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+				return http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "{}", "reasoning_content": "thinking"}}], "usage": {"prompt_tokens": 9, "completion_tokens": 4}}`
+			})
+			backend := NewOpenAIBackend(server.URL+"/v1/", "secret", "qwen3", time.Minute)
+			backend.SetJSONMode(tt.jsonMode)
+			backend.SetTemplateKwargs(!tt.noKwargs)
+
+			opts := testOptions
+			opts.JSONSchema, opts.Grammar, opts.FreeForm, opts.Think = tt.opts.JSONSchema, tt.opts.Grammar, tt.opts.FreeForm, tt.opts.Think
+			resp, err := backend.Generate(context.Background(), &Request{System: "system", Prompt: "prompt", Options: opts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "{}" || resp.Reasoning != "thinking" || resp.Usage.PromptTokens != 9 || resp.Usage.CompletionTokens != 4 {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+
+			if got := server.header.Get("Authorization"); got != "Bearer secret" {
+				t.Errorf("Authorization: got %q", got)
+			}
+			body := server.bodies["/v1/chat/completions"]
+			checkFields(t, body, map[string]interface{}{
+				"model":           "qwen3",
+				"max_tokens":      256,
+				"temperature":     0.3,
+				"top_p":           0.9,
+				"top_k":           40,
+				"min_p":           0.05,
+				"seed":            42,
+				"stream":          nil,
+				"grammar":         nil, // OpenAI兼容接口不支持GBNF语法
+				"response_format": tt.want,
+				// 未开启思考模式时也要显式关闭
+				"chat_template_kwargs": tt.wantKwargs,
+			})
+		})
+	}
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+		return http.StatusOK, "data: {\"choices\": [{\"delta\": {\"reasoning_content\": \"hmm\"}}]}\n\n" +
+			"data: {\"choices\": [{\"delta\": {\"content\": \"{\\\"type\\\"\"}}]}\n\n" +
+			"data: {\"choices\": [{\"delta\": {\"content\": \": \\\"fix\\\"}\"}}]}\n\n" +
+			"data: {\"choices\": [], \"usage\": {\"prompt_tokens\": 7, \"completion_tokens\": 6}}\n\n" +
+			"data: [DONE]\n\n"
+	})
+	backend := NewOpenAIBackend(server.URL+"/v1", "", "qwen3", time.Minute)
+
+	var streamed strings.Builder
+	req := &Request{Prompt: "prompt", Options: testOptions, Stream: func(text string) { streamed.WriteString(text) }}
+	resp, err := backend.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type": "fix"}`; resp.Text != want || streamed.String() != want {
+		t.Errorf("got text %q, streamed %q, want %q", resp.Text, streamed.String(), want)
+	}
+	if resp.Reasoning != "hmm" || resp.Usage.PromptTokens != 7 || resp.Usage.CompletionTokens != 6 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if got := server.header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization sent without an API key: %q", got)
+	}
+	checkFields(t, server.bodies["/v1/chat/completions"], map[string]interface{}{
+		"stream":         true,
+		"stream_options": map[string]interface{}{"include_usage": true},
+	})
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	server := newFakeServer(t, func(path string, body map[string]interface{}) (int, string) {
+		return http.StatusUnauthorized, `{"error": {"message": "invalid api key"}}`
+	})
+	backend := NewOpenAIBackend(server.URL+"/v1", "bad", "qwen3", time.Minute)
+
+	for _, stream := range []func(string){nil, func(string) {}} {
+		_, err := backend.Generate(context.Background(), &Request{Prompt: "prompt", Options: testOptions, Stream: stream})
+		if !hasCode(err, i18n.AIOpenAIFailed) || !hasCode(err, i18n.AIHTTPStatus) {
+			t.Fatalf("stream=%v: want HTTP status error, got %v", stream != nil, err)
+		}
+		if !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid api key") {
+			t.Errorf("stream=%v: error does not contain the status and response body: %v", stream != nil, err)
+		}
+	}
+}
+
+func TestHTTPBackendParallel(t *testing.T) {
+	openai := NewOpenAIBackend("", "", "qwen3", time.Minute)
+	ollama := NewOllamaBackend("", "", "")
+	for _, backend := range []interface {
+		ConcurrentBackend
+		SetParallel(int)
+	}{openai, ollama} {
+		if got := backend.MaxConcurrency(); got != DefaultParallel {
+			t.Errorf("%T: got %d, want DefaultParallel", backend, got)
+		}
+		backend.SetParallel(1)
+		if got := maxConcurrency(backend.(Backend)); got != 1 {
+			t.Errorf("%T: got %d, want the configured 1", backend, got)
+		}
+	}
+}
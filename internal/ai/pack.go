@@ -0,0 +1,216 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffFile 表示一个文件的diff，按hunk拆分
+type diffFile struct {
+	name   string   // 文件名
+	header string   // 第一个hunk之前的内容（diff --git、index、---/+++等）
+	hunks  []string // 以@@开头的各个hunk
+}
+
+// parseDiffFile 把单个文件的diff拆分为文件头和hunk
+func parseDiffFile(fileDiff string) diffFile {
+	file := diffFile{name: extractFileName(fileDiff)}
+
+	lines := strings.SplitAfter(fileDiff, "\n")
+	var current strings.Builder
+	inHunk := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			if inHunk {
+				file.hunks = append(file.hunks, current.String())
+			} else {
+				file.header = current.String()
+			}
+			current.Reset()
+			inHunk = true
+		}
+		current.WriteString(line)
+	}
+	if inHunk {
+		file.hunks = append(file.hunks, current.String())
+	} else {
+		file.header = current.String()
+	}
+
+	return file
+}
+
+// PromptFile 是打包后单个文件的diff，作为提示词模板的数据
+type PromptFile struct {
+	Name         string   // 文件名
+	Header       string   // 文件头（diff --git、index、---/+++等）
+	Hunks        []string // 保留的hunk，Truncated为true时第一个hunk只保留了开头部分
+	Truncated    bool     // 第一个hunk是否被按行截断
+	OmittedHunks int      // 因长度限制省略的hunk数
+	OmittedLines int      // 省略的hunk的总行数
+	Omitted      bool     // 整个文件是否因长度限制被省略
+}
+
+// packDiff 按token预算打包diff内容：
+// 能放下时包含完整diff，否则在文件之间平均分配预算，在hunk边界截断并标出省略的内容
+func packDiff(p *promptLang, rawDiff string, budget int, countTokens func(string) int) string {
+	text, _ := packDiffFiles(p, rawDiff, budget, countTokens)
+	return text
+}
+
+// packDiffFiles 与packDiff相同，同时返回每个文件保留和省略的内容
+func packDiffFiles(p *promptLang, rawDiff string, budget int, countTokens func(string) int) (string, []PromptFile) {
+	fileDiffs := splitDiffByFile(rawDiff)
+	files := make([]diffFile, len(fileDiffs))
+	for i, fileDiff := range fileDiffs {
+		files[i] = parseDiffFile(fileDiff)
+	}
+
+	if countTokens(rawDiff) <= budget {
+		packed := make([]PromptFile, len(files))
+		for i, file := range files {
+			packed[i] = PromptFile{Name: file.name, Header: file.header, Hunks: file.hunks}
+		}
+		return p.diff + "```\n" + rawDiff + "\n```\n", packed
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.diffSummary)
+
+	sizes := make([]int, len(fileDiffs))
+	for i, fileDiff := range fileDiffs {
+		sizes[i] = countTokens(fileDiff)
+	}
+
+	// 小文件优先，放不满配额时剩余的预算留给后面的大文件
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sizes[order[a]] < sizes[order[b]]
+	})
+
+	budget -= countTokens(sb.String())
+	texts := make([]string, len(files))
+	packed := make([]PromptFile, len(files))
+	remaining := budget
+	for k, idx := range order {
+		quota := remaining / (len(order) - k)
+		text, file, used := packFile(p, files[idx], quota, countTokens)
+		texts[idx] = text
+		packed[idx] = file
+		remaining -= used
+	}
+
+	omitted := []string{}
+	for i, text := range texts {
+		if text == "" {
+			omitted = append(omitted, files[i].name)
+			continue
+		}
+		sb.WriteString(text)
+	}
+	if len(omitted) > 0 {
+		sb.WriteString(fmt.Sprintf(p.omittedFiles, len(omitted), strings.Join(omitted, ", ")))
+	}
+
+	return sb.String(), packed
+}
+
+// packFile 在quota个token以内打包单个文件的diff，返回打包结果、保留的内容和使用的token数，
+// 连文件头都放不下时返回空字符串
+func packFile(p *promptLang, file diffFile, quota int, countTokens func(string) int) (string, PromptFile, int) {
+	packed := PromptFile{Name: file.name, Header: file.header}
+	open := fmt.Sprintf(p.file, file.name) + "```\n"
+	const closing = "\n```\n"
+
+	used := countTokens(open) + countTokens(closing) + countTokens(file.header)
+	if used > quota {
+		packed.Omitted = true
+		packed.OmittedHunks = len(file.hunks)
+		for _, hunk := range file.hunks {
+			packed.OmittedLines += strings.Count(hunk, "\n")
+		}
+		return "", packed, 0
+	}
+
+	var sb strings.Builder
+	sb.WriteString(open)
+	sb.WriteString(file.header)
+
+	// 为省略标记预留空间
+	reserve := countTokens(fmt.Sprintf(p.omittedHunks, 99, 9999))
+	kept := 0
+	for i, hunk := range file.hunks {
+		size := countTokens(hunk)
+		// 最后一个hunk放得下时不需要省略标记
+		if i < len(file.hunks)-1 {
+			size += reserve
+		}
+		if used+size > quota {
+			break
+		}
+		if i < len(file.hunks)-1 {
+			size -= reserve
+		}
+		sb.WriteString(hunk)
+		packed.Hunks = append(packed.Hunks, hunk)
+		used += size
+		kept++
+	}
+	rest := file.hunks[kept:]
+
+	// 一个hunk都放不下时，按行保留第一个hunk的开头部分
+	if kept == 0 && len(rest) > 0 {
+		text, size, dropped := truncateHunk(rest[0], quota-used-2*reserve, countTokens)
+		if text != "" {
+			sb.WriteString(text)
+			packed.Hunks = append(packed.Hunks, text)
+			packed.Truncated = true
+			used += size
+			marker := fmt.Sprintf(p.truncatedHunk, dropped)
+			sb.WriteString(marker)
+			used += countTokens(marker)
+			rest = rest[1:]
+		}
+	}
+
+	if len(rest) > 0 {
+		lines := 0
+		for _, hunk := range rest {
+			lines += strings.Count(hunk, "\n")
+		}
+		marker := fmt.Sprintf(p.omittedHunks, len(rest), lines)
+		sb.WriteString(marker)
+		used += countTokens(marker)
+		packed.OmittedHunks = len(rest)
+		packed.OmittedLines = lines
+	}
+
+	sb.WriteString(closing)
+	return sb.String(), packed, used
+}
+
+// truncateHunk 按行截取hunk的开头部分，返回截取结果、使用的token数和未显示的行数
+func truncateHunk(hunk string, quota int, countTokens func(string) int) (string, int, int) {
+	lines := strings.SplitAfter(hunk, "\n")
+	var sb strings.Builder
+	used := 0
+	for i, line := range lines {
+		size := countTokens(line)
+		if used+size > quota {
+			dropped := 0
+			for _, rest := range lines[i:] {
+				if rest != "" {
+					dropped++
+				}
+			}
+			return sb.String(), used, dropped
+		}
+		sb.WriteString(line)
+		used += size
+	}
+	return sb.String(), used, 0
+}
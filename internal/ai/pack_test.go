@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// runeCount 是测试使用的确定的token计数：每个字符一个token
+func runeCount(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// fileDiff 拼接一个文件的diff，hunkLines是每个hunk添加的行数，每行的内容为line
+func fileDiff(name, line string, hunkLines ...int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\nindex 1111111..2222222 100644\n--- a/%s\n+++ b/%s\n", name, name, name, name)
+	for i, n := range hunkLines {
+		fmt.Fprintf(&sb, "@@ -%d,0 +%d,%d @@\n", i*100+1, i*100+1, n)
+		for j := 0; j < n; j++ {
+			fmt.Fprintf(&sb, "+%s %d\n", line, j)
+		}
+	}
+	return sb.String()
+}
+
+// fileCost 返回完整打包一个文件需要的token数
+func fileCost(p *promptLang, diff string) int {
+	name := extractFileName(diff)
+	return runeCount(fmt.Sprintf(p.file, name)+"```\n") + runeCount(diff) + runeCount("\n```\n")
+}
+
+func TestPackDiffFits(t *testing.T) {
+	p := promptLangs[LangEn]
+	rawDiff := fileDiff("a.go", "added line", 2, 3) + fileDiff("b.go", "added line", 1)
+
+	text, files := packDiffFiles(p, rawDiff, runeCount(rawDiff), runeCount)
+	if text != p.diff+"```\n"+rawDiff+"\n```\n" {
+		t.Errorf("diff that fits is not included verbatim:\n%s", text)
+	}
+	if len(files) != 2 || files[0].Name != "a.go" || len(files[0].Hunks) != 2 || len(files[1].Hunks) != 1 {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	for _, file := range files {
+		if file.Truncated || file.Omitted || file.OmittedHunks != 0 {
+			t.Errorf("%s: nothing should be omitted: %+v", file.Name, file)
+		}
+	}
+}
+
+func TestPackDiffHunkBoundary(t *testing.T) {
+	p := promptLangs[LangEn]
+	diff := fileDiff("a.go", "added line", 5, 5, 5)
+	parsed := parseDiffFile(diff)
+	reserve := runeCount(fmt.Sprintf(p.omittedHunks, 99, 9999))
+
+	// 能放下前两个hunk和省略标记，放不下第三个
+	budget := runeCount(p.diffSummary) + fileCost(p, diff) - runeCount(parsed.hunks[2]) + reserve
+	text, files := packDiffFiles(p, diff, budget, runeCount)
+
+	file := files[0]
+	if len(file.Hunks) != 2 || file.Hunks[0] != parsed.hunks[0] || file.Hunks[1] != parsed.hunks[1] {
+		t.Errorf("got hunks %q, want the first two", file.Hunks)
+	}
+	if file.Truncated || file.Omitted || file.OmittedHunks != 1 || file.OmittedLines != 6 {
+		t.Errorf("unexpected file: %+v", file)
+	}
+	if !strings.HasPrefix(text, p.diffSummary) || !strings.Contains(text, fmt.Sprintf(p.omittedHunks, 1, 6)) {
+		t.Errorf("missing the abridged title or the omitted hunks marker:\n%s", text)
+	}
+	if strings.Contains(text, "@@ -201") {
+		t.Errorf("third hunk should be omitted:\n%s", text)
+	}
+	if n := runeCount(text); n > budget {
+		t.Errorf("packed %d tokens, budget %d", n, budget)
+	}
+}
+
+func TestPackDiffTruncateHunk(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		line string
+	}{
+		{name: "ascii", line: "added line"},
+		{name: "multibyte", line: "修改缓存的键，加入模型的哈希"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := promptLangs[LangZh]
+			diff := fileDiff("a.go", tt.line, 40)
+			parsed := parseDiffFile(diff)
+			reserve := runeCount(fmt.Sprintf(p.omittedHunks, 99, 9999))
+
+			// 单个hunk放不下，只能保留开头的几行
+			budget := runeCount(p.diffSummary) + fileCost(p, diff) - runeCount(parsed.hunks[0]) + 2*reserve + 5*runeCount("+"+tt.line+" 10\n")
+			text, files := packDiffFiles(p, diff, budget, runeCount)
+
+			file := files[0]
+			if !file.Truncated || file.Omitted || file.OmittedHunks != 0 || len(file.Hunks) != 1 {
+				t.Fatalf("unexpected file: %+v", file)
+			}
+			kept := file.Hunks[0]
+			if !strings.HasPrefix(parsed.hunks[0], kept) || !strings.HasSuffix(kept, "\n") {
+				t.Errorf("truncated hunk is not a line prefix of the hunk: %q", kept)
+			}
+			keptLines := strings.Count(kept, "\n")
+			if keptLines < 2 || keptLines >= 41 {
+				t.Errorf("kept %d lines", keptLines)
+			}
+			if marker := fmt.Sprintf(p.truncatedHunk, 41-keptLines); !strings.Contains(text, marker) {
+				t.Errorf("missing marker %q:\n%s", marker, text)
+			}
+			if !utf8.ValidString(text) {
+				t.Error("packed diff is not valid UTF-8")
+			}
+			if n := runeCount(text); n > budget {
+				t.Errorf("packed %d tokens, budget %d", n, budget)
+			}
+		})
+	}
+}
+
+func TestPackDiffOmitsFiles(t *testing.T) {
+	p := promptLangs[LangEn]
+	small := fileDiff("a.go", "added line", 1)
+	medium := fileDiff("b.go", "added line", 3)
+	// 文件名很长，连文件头都放不下
+	longName := strings.Repeat("deeply/nested/", 20) + "c.go"
+	large := fileDiff(longName, "added line", 30)
+	rawDiff := large + small + medium
+
+	// 小文件优先：a和b完整保留，剩余的预算放不下c的文件头
+	budget := runeCount(p.diffSummary) + 3*fileCost(p, medium)
+	text, files := packDiffFiles(p, rawDiff, budget, runeCount)
+
+	if len(files) != 3 {
+		t.Fatalf("got %d files", len(files))
+	}
+	c, a, b := files[0], files[1], files[2]
+	if !c.Omitted || c.OmittedHunks != 1 || c.OmittedLines != 31 || len(c.Hunks) != 0 {
+		t.Errorf("c.go should be omitted: %+v", c)
+	}
+	for _, file := range []PromptFile{a, b} {
+		if file.Omitted || file.Truncated || file.OmittedHunks != 0 || len(file.Hunks) != 1 {
+			t.Errorf("%s should be kept whole: %+v", file.Name, file)
+		}
+	}
+	if marker := fmt.Sprintf(p.omittedFiles, 1, longName); !strings.HasSuffix(text, marker) {
+		t.Errorf("missing marker %q:\n%s", marker, text)
+	}
+	// 保持原有的文件顺序
+	if strings.Index(text, "File: a.go") > strings.Index(text, "File: b.go") {
+		t.Errorf("files are out of order:\n%s", text)
+	}
+}
+
+func TestPackDiffRedistributesQuota(t *testing.T) {
+	p := promptLangs[LangEn]
+	small := fileDiff("a.go", "added line", 1)
+	large := fileDiff("b.go", "added line", 10, 10, 10, 10, 10, 10)
+	rawDiff := small + large
+
+	budget := runeCount(rawDiff) - 1
+	_, files := packDiffFiles(p, rawDiff, budget, runeCount)
+
+	// 小文件用不完的配额留给大文件，大文件得到的远多于平均分配的一半
+	used := 0
+	for _, hunk := range files[1].Hunks {
+		used += runeCount(hunk)
+	}
+	if used <= budget/2 {
+		t.Errorf("large file kept %d tokens, not more than an even split of %d", used, budget)
+	}
+	if files[0].OmittedHunks != 0 || files[1].OmittedHunks == 0 {
+		t.Errorf("unexpected files: %+v", files)
+	}
+}
+
+func TestPackDiffBudgetBelowHeader(t *testing.T) {
+	p := promptLangs[LangEn]
+	rawDiff := fileDiff("a.go", "added line", 2) + fileDiff("b.go", "added line", 2)
+
+	for _, budget := range []int{0, runeCount(p.diffSummary) - 1} {
+		text, files := packDiffFiles(p, rawDiff, budget, runeCount)
+		if want := p.diffSummary + fmt.Sprintf(p.omittedFiles, 2, "a.go, b.go"); text != want {
+			t.Errorf("budget %d: got %q, want %q", budget, text, want)
+		}
+		for _, file := range files {
+			if !file.Omitted || file.OmittedHunks != 1 || file.OmittedLines != 3 {
+				t.Errorf("budget %d: %s should be omitted: %+v", budget, file.Name, file)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"strings"
+)
+
+// GenerationParams 记录生成commit message时实际使用的参数，用于复现结果
+type GenerationParams struct {
+	Backend     string  `json:"backend"`                // 推理后端
+	Model       string  `json:"model"`                  // 模型文件路径或模型名称
+	Temperature float64 `json:"temperature"`            // 生成温度
+	MaxTokens   int     `json:"max_tokens"`             // 最大生成的token数
+	TopP        float64 `json:"top_p"`                  // top-p
+	TopK        int     `json:"top_k"`                  // top-k
+	MinP        float64 `json:"min_p"`                  // min-p
+	Seed        int     `json:"seed"`                   // 随机种子
+	Threads     int     `json:"threads,omitempty"`      // 推理线程数
+	ContextSize int     `json:"context_size,omitempty"` // 上下文长度
+	Timeout     string  `json:"timeout"`                // 单次生成的超时时间
+	Think       bool    `json:"think,omitempty"`        // 是否使用思考模式
+	Fallback    string  `json:"fallback,omitempty"`     // 模型生成失败、改用启发式规则生成时的错误
+}
+
+// seedFor 返回第variant个候选使用的随机种子：未指定种子时使用随机生成的种子，以便记录和复现
+func (c *Client) seedFor(variant int) int {
+	seed := c.seed
+	if seed < 0 {
+		seed = c.randomSeed
+	}
+	return seed + variant
+}
+
+// requestOptions 返回第variant个候选的生成参数，后面的候选使用更高的温度和不同的种子
+func (c *Client) requestOptions(variant int) Options {
+	return Options{
+		Temperature: c.temperature + candidateTemperatureStep*float64(variant),
+		MaxTokens:   c.generationTokens(),
+		TopP:        c.topP,
+		TopK:        c.topK,
+		MinP:        c.minP,
+		Seed:        c.seedFor(variant),
+		Think:       c.think,
+	}
+}
+
+// generationParams 返回第variant个候选实际使用的参数
+func (c *Client) generationParams(variant int) *GenerationParams {
+	opts := c.requestOptions(variant)
+	backendName := strings.ToLower(c.backendName)
+	if backendName == "" {
+		backendName = BackendLlamaCLI
+	}
+
+	params := &GenerationParams{
+		Backend:     backendName,
+		Temperature: opts.Temperature,
+		MaxTokens:   opts.MaxTokens,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		MinP:        opts.MinP,
+		Seed:        opts.Seed,
+		Threads:     c.threads,
+		ContextSize: c.ctxSize,
+		Timeout:     c.timeout.String(),
+		Think:       opts.Think,
+	}
+	switch backendName {
+	case BackendLlamaCLI, BackendLlamaServer:
+		params.Model = c.modelPath
+		params.ContextSize = c.contextSize()
+	case BackendOpenAI:
+		params.Model = c.modelName
+	case BackendOllama:
+		params.Model = c.ollamaModel
+	}
+	return params
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package ai
+
+import "os/exec"
+
+// detachProcess 在非unix平台上不做处理
+func detachProcess(cmd *exec.Cmd) {}
+
+// setProcessGroup 在非unix平台上不做处理，取消时只结束子进程本身
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup 在非unix平台上只结束子进程本身
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package ai
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess 让子进程脱离当前会话，aimmit退出或收到Ctrl-C后仍继续运行
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// setProcessGroup 让子进程在独立的进程组中运行，上下文取消时结束整个进程组
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
+
+// killProcessGroup 结束子进程所在的整个进程组
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
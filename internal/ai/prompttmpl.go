@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// commitTemplateName 是生成CommitMessage的提示词模板的文件名（不含扩展名）
+const commitTemplateName = "commit"
+
+// PromptDirs 返回查找提示词模板的目录：仓库中的.aimmit/prompts优先，其次是用户配置目录中的aimmit/prompts
+func PromptDirs(repoRoot string) []string {
+	dirs := []string{filepath.Join(repoRoot, ".aimmit", "prompts")}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(configDir, "aimmit", "prompts"))
+	}
+	return dirs
+}
+
+// PromptSummary 是分段总结时单个文件的变更摘要，作为提示词模板的数据
+type PromptSummary struct {
+	Name    string // 文件名
+	Summary string // 变更摘要
+}
+
+// PromptData 是提示词模板的数据
+type PromptData struct {
+	Lang         string          // commit message使用的语言
+	DiffInfo     *git.DiffInfo   // 完整的差异信息，RawDiff未按token预算截断
+	Files        []string        // 修改的文件列表
+	Additions    int             // 添加的行数
+	Deletions    int             // 删除的行数
+	Diff         string          // 按token预算打包后的差异详情（含标题），分段总结时为空
+	DiffFiles    []PromptFile    // 打包后每个文件保留和省略的内容，分段总结时为空
+	Truncated    bool            // 差异详情是否因token预算被截断
+	Summaries    []PromptSummary // 分段总结时各文件的变更摘要
+	Examples     []string        // 从提交历史中选出的约定式提交标题
+	Types        []string        // 可选的提交类型
+	Scopes       []string        // 可选的范围，没有限定范围时为空
+	Instructions string          // 内置的返回格式说明
+	Default      string          // 内置模板生成的完整提示词，已经包含Diff或Summaries
+}
+
+// promptFuncs 是提示词模板中可以使用的函数
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+	"add":  func(a, b int) int { return a + b },
+}
+
+// SetPromptDirs 设置查找提示词模板的目录，靠前的目录优先
+func (c *Client) SetPromptDirs(dirs []string) {
+	c.promptDirs = dirs
+	c.commitTmpl = nil
+	c.commitTmplLoaded = false
+}
+
+// commitTemplate 加载并缓存生成CommitMessage的提示词模板，没有自定义模板时返回nil。
+// 每个目录中按 commit.<语言>.tmpl、commit.tmpl 的顺序查找
+func (c *Client) commitTemplate() (*template.Template, error) {
+	if c.commitTmplLoaded {
+		return c.commitTmpl, nil
+	}
+	path := findPromptTemplate(c.promptDirs, commitTemplateName, c.lang)
+	if path != "" {
+		tmpl, err := template.New(filepath.Base(path)).Funcs(promptFuncs).ParseFiles(path)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.AIPromptTemplateLoad, path)
+		}
+		if c.debug {
+			fmt.Println(i18n.T(i18n.AIDebugPromptTemplate, path))
+		}
+		c.commitTmpl = tmpl
+	}
+	c.commitTmplLoaded = true
+	return c.commitTmpl, nil
+}
+
+// findPromptTemplate 在dirs中查找名为name的提示词模板，找不到时返回空字符串
+func findPromptTemplate(dirs []string, name, lang string) string {
+	for _, dir := range dirs {
+		for _, file := range []string{name + "." + lang + ".tmpl", name + ".tmpl"} {
+			path := filepath.Join(dir, file)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// promptData 返回提示词模板中与diff打包方式无关的数据
+func (c *Client) promptData(diffInfo *git.DiffInfo, examples []string, defaultPrompt string) *PromptData {
+	var sb strings.Builder
+	writeResponseInstructions(&sb, c.prompts(), c.rules)
+	return &PromptData{
+		Lang:         c.lang,
+		DiffInfo:     diffInfo,
+		Files:        diffInfo.Files,
+		Additions:    diffInfo.Additions,
+		Deletions:    diffInfo.Deletions,
+		Examples:     examples,
+		Types:        allowedTypes(c.rules),
+		Scopes:       allowedScopes(c.rules),
+		Instructions: sb.String(),
+		Default:      defaultPrompt,
+	}
+}
+
+// renderDiffPrompt 构建直接打包diff的提示信息，有自定义模板时使用模板，否则使用内置模板
+func (c *Client) renderDiffPrompt(diffInfo *git.DiffInfo, diffBudget int, countTokens func(string) int) (string, error) {
+	p := c.prompts()
+	examples := c.examples(diffInfo.Files)
+	defaultPrompt := buildDiffPrompt(p, diffInfo, examples, c.rules, diffBudget, countTokens)
+	tmpl, err := c.commitTemplate()
+	if err != nil || tmpl == nil {
+		return defaultPrompt, err
+	}
+
+	data := c.promptData(diffInfo, examples, defaultPrompt)
+	rawDiff := promptDiff(p, diffInfo)
+	data.Diff, data.DiffFiles = packDiffFiles(p, rawDiff, diffBudget, countTokens)
+	data.Truncated = countTokens(rawDiff) > diffBudget
+	return executePromptTemplate(tmpl, data)
+}
+
+// renderSummaryPrompt 用各文件的变更摘要构建提示信息，有自定义模板时使用模板，否则使用内置模板
+func (c *Client) renderSummaryPrompt(diffInfo *git.DiffInfo, summaries []fileSummary) (string, error) {
+	examples := c.examples(diffInfo.Files)
+	defaultPrompt := buildSummaryPrompt(c.prompts(), diffInfo, examples, c.rules, summaries)
+	tmpl, err := c.commitTemplate()
+	if err != nil || tmpl == nil {
+		return defaultPrompt, err
+	}
+
+	data := c.promptData(diffInfo, examples, defaultPrompt)
+	for _, s := range summaries {
+		data.Summaries = append(data.Summaries, PromptSummary{Name: s.name, Summary: s.summary})
+	}
+	return executePromptTemplate(tmpl, data)
+}
+
+// executePromptTemplate 用data渲染提示词模板
+func executePromptTemplate(tmpl *template.Template, data *PromptData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", i18n.Wrap(err, i18n.AIPromptTemplateExec, tmpl.Name())
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// writeTemplates 在临时目录中写入提示词模板，返回目录
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// templateDiff 返回提示词模板测试使用的diff
+func templateDiff() *git.DiffInfo {
+	rawDiff := fileDiff("a.go", "added line", 2) + fileDiff("b.go", "added line", 1, 1)
+	return &git.DiffInfo{Files: []string{"a.go", "b.go"}, RawDiff: rawDiff, Additions: 4, Deletions: 1}
+}
+
+func TestPromptTemplate(t *testing.T) {
+	diffInfo := templateDiff()
+	budget := 100000
+	builtin := buildDiffPrompt(promptLangs[LangEn], diffInfo, nil, nil, budget, runeCount)
+
+	tests := []struct {
+		name      string
+		templates map[string]string
+		want      string
+	}{
+		{name: "built-in", templates: map[string]string{}, want: builtin},
+		{
+			name:      "user template",
+			templates: map[string]string{"commit.tmpl": `{{.Lang}}: {{join .Files ", "}} +{{.Additions}} -{{.Deletions}} {{join .Types "|"}}`},
+			want:      "en: a.go, b.go +4 -1 feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert",
+		},
+		{
+			name:      "language template first",
+			templates: map[string]string{"commit.tmpl": "generic", "commit.en.tmpl": "english"},
+			want:      "english",
+		},
+		{
+			name:      "other language template is ignored",
+			templates: map[string]string{"commit.zh.tmpl": "chinese"},
+			want:      builtin,
+		},
+		{
+			name:      "default prompt",
+			templates: map[string]string{"commit.tmpl": "Be brief.\n{{.Default}}"},
+			want:      "Be brief.\n" + builtin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(false)
+			c.SetLang(LangEn)
+			c.SetPromptDirs([]string{writeTemplates(t, tt.templates)})
+			got, err := c.renderDiffPrompt(diffInfo, budget, runeCount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// 靠前的目录优先
+	c := NewClient(false)
+	c.SetPromptDirs([]string{
+		writeTemplates(t, map[string]string{"commit.tmpl": "repo"}),
+		writeTemplates(t, map[string]string{"commit.tmpl": "user"}),
+	})
+	if got, err := c.renderDiffPrompt(diffInfo, budget, runeCount); err != nil || got != "repo" {
+		t.Errorf("got %q, %v, want the first directory's template", got, err)
+	}
+}
+
+func TestPromptTemplateErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		template string
+		code     i18n.Code
+	}{
+		{name: "syntax", template: "{{.Lang", code: i18n.AIPromptTemplateLoad},
+		{name: "unknown function", template: "{{upper .Lang}}", code: i18n.AIPromptTemplateLoad},
+		{name: "unknown field", template: "{{.Missing}}", code: i18n.AIPromptTemplateExec},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(false)
+			c.SetPromptDirs([]string{writeTemplates(t, map[string]string{"commit.tmpl": tt.template})})
+			if _, err := c.renderDiffPrompt(templateDiff(), 100000, runeCount); i18n.CodeOf(err) != tt.code {
+				t.Errorf("want %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestPromptTemplateFiles(t *testing.T) {
+	const tmpl = `{{range .DiffFiles}}{{.Name}} hunks={{len .Hunks}} omitted={{.Omitted}} truncated={{.Truncated}} omitted_hunks={{.OmittedHunks}} omitted_lines={{.OmittedLines}}
+{{end}}truncated={{.Truncated}}`
+	diffInfo := templateDiff()
+	c := NewClient(false)
+	c.SetPromptDirs([]string{writeTemplates(t, map[string]string{"commit.tmpl": tmpl})})
+
+	got, err := c.renderDiffPrompt(diffInfo, 100000, runeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.go hunks=1 omitted=false truncated=false omitted_hunks=0 omitted_lines=0\n" +
+		"b.go hunks=2 omitted=false truncated=false omitted_hunks=0 omitted_lines=0\n" +
+		"truncated=false"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// 预算不足时所有文件都被省略
+	got, err = c.renderDiffPrompt(diffInfo, 0, runeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "a.go hunks=0 omitted=true truncated=false omitted_hunks=1 omitted_lines=3\n" +
+		"b.go hunks=0 omitted=true truncated=false omitted_hunks=2 omitted_lines=4\n" +
+		"truncated=true"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
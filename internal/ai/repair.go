@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// buildRepairPrompt 把无法解析的回复连同修复要求追加到原始提示之后，用于重新生成
+func buildRepairPrompt(p *promptLang, prompt, badOutput string, parseErr *ParseError) string {
+	var sb strings.Builder
+
+	sb.WriteString(prompt)
+	sb.WriteString(p.repairReason)
+	sb.WriteString(parseErr.Localize(p.locale))
+	sb.WriteString(p.repairOutput)
+	sb.WriteString("```\n")
+	sb.WriteString(strings.TrimSpace(badOutput))
+	sb.WriteString("\n```\n")
+	sb.WriteString(fmt.Sprintf(p.repairAsk, strings.Join(commitMessageFieldNames(), p.fieldSep)))
+
+	return sb.String()
+}
+
+// commitMessageFieldNames 返回CommitMessage中需要模型生成的字段名
+func commitMessageFieldNames() []string {
+	names := []string{}
+	for _, field := range commitMessageFields() {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+// completeJSON 尝试补全被截断的JSON（例如生成超时时的部分输出）：
+// 闭合未结束的字符串、对象和数组，去掉末尾多余的逗号
+func completeJSON(partial string) string {
+	start := strings.Index(partial, "{")
+	if start == -1 {
+		return partial
+	}
+	partial = partial[start:]
+
+	var stack []byte
+	inString, escaped := false, false
+	// inKey 表示当前字符串是对象的键，pendingKey 表示键已结束但还没有值
+	inKey, pendingKey := false, false
+	last := byte(0) // 上一个字符串外的非空白字符
+	for i := 0; i < len(partial); i++ {
+		ch := partial[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"':
+			if inString {
+				pendingKey = inKey
+				inKey = false
+			} else {
+				inKey = len(stack) > 0 && stack[len(stack)-1] == '}' && (last == '{' || last == ',')
+			}
+			inString = !inString
+			last = ch
+		case inString:
+		case ch == ':':
+			pendingKey = false
+			last = ch
+		case ch == '{':
+			stack = append(stack, '}')
+			last = ch
+		case ch == '[':
+			stack = append(stack, ']')
+			last = ch
+		case (ch == '}' || ch == ']') && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				// 第一个完整的对象已经结束
+				return partial[:i+1]
+			}
+			last = ch
+		case ch != ' ' && ch != '\t' && ch != '\r' && ch != '\n':
+			last = ch
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(partial)
+	if escaped {
+		sb.WriteString("\\")
+	}
+	if inString {
+		sb.WriteString("\"")
+	}
+
+	result := strings.TrimRight(sb.String(), " \t\r\n")
+	switch {
+	case strings.HasSuffix(result, ","):
+		result = strings.TrimSuffix(result, ",")
+	case strings.HasSuffix(result, ":"), inKey, pendingKey:
+		result = strings.TrimSuffix(result, ":") + ":null"
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		result += string(stack[i])
+	}
+
+	return result
+}
@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// replayFixture 是回放数据文件的内容
+type replayFixture struct {
+	Backend   string `json:"backend"`             // 录制时使用的后端
+	System    string `json:"system"`              // 系统提示
+	Prompt    string `json:"prompt"`              // 用户提示
+	Response  string `json:"response"`            // 模型回复
+	Reasoning string `json:"reasoning,omitempty"` // 单独返回的推理内容
+	Usage     Usage  `json:"usage"`               // token用量
+}
+
+// PromptHash 计算请求提示词的哈希，作为回放数据的键
+func PromptHash(req *Request) string {
+	sum := sha256.Sum256([]byte(req.System + "\x00" + req.Prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// fixturePath 返回请求对应的回放数据文件路径
+func fixturePath(dir string, req *Request) string {
+	return filepath.Join(dir, PromptHash(req)+".json")
+}
+
+// ReplayBackend 按提示词哈希返回预先录制的回复，不需要模型，结果完全确定
+type ReplayBackend struct {
+	dir string // 回放数据目录
+}
+
+// NewReplayBackend 创建一个新的回放后端
+func NewReplayBackend(dir string) *ReplayBackend {
+	return &ReplayBackend{dir: dir}
+}
+
+// Name 返回后端名称
+func (b *ReplayBackend) Name() string {
+	return BackendReplay
+}
+
+// MaxConcurrency 回放数据可以并发读取
+func (b *ReplayBackend) MaxConcurrency() int {
+	return 8
+}
+
+// Generate 读取与提示词对应的回放数据
+func (b *ReplayBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	path := fixturePath(b.dir, req)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, i18n.New(i18n.AIReplayNotFound, path)
+		}
+		return nil, i18n.Wrap(err, i18n.AIReplayRead)
+	}
+
+	var fixture replayFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, i18n.Wrap(err, i18n.AIReplayParse)
+	}
+
+	if req.Stream != nil {
+		req.Stream(fixture.Response)
+	}
+	return &Response{Text: fixture.Response, Reasoning: fixture.Reasoning, Usage: fixture.Usage}, nil
+}
+
+// RecordingBackend 包装一个真实后端，把每次的回复录制为回放数据
+type RecordingBackend struct {
+	backend Backend // 被录制的后端
+	dir     string  // 回放数据目录
+}
+
+// NewRecordingBackend 创建一个新的录制后端
+func NewRecordingBackend(backend Backend, dir string) *RecordingBackend {
+	return &RecordingBackend{backend: backend, dir: dir}
+}
+
+// Name 返回被录制后端的名称
+func (b *RecordingBackend) Name() string {
+	return b.backend.Name()
+}
+
+// MaxConcurrency 与被录制的后端一致
+func (b *RecordingBackend) MaxConcurrency() int {
+	return maxConcurrency(b.backend)
+}
+
+// RenderPrompt 使用被录制后端的提示词格式
+func (b *RecordingBackend) RenderPrompt(req *Request) string {
+	if renderer, ok := b.backend.(PromptRenderer); ok {
+		return renderer.RenderPrompt(req)
+	}
+	return renderMessages(req)
+}
+
+// Generate 调用被录制的后端并保存回复
+func (b *RecordingBackend) Generate(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := b.backend.Generate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	fixture := replayFixture{
+		Backend:   b.backend.Name(),
+		System:    req.System,
+		Prompt:    req.Prompt,
+		Response:  resp.Text,
+		Reasoning: resp.Reasoning,
+		Usage:     resp.Usage,
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.AIReplayMarshal)
+	}
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return nil, i18n.Wrap(err, i18n.AIReplayMkdir)
+	}
+	if err := os.WriteFile(fixturePath(b.dir, req), data, 0o644); err != nil {
+		return nil, i18n.Wrap(err, i18n.AIReplaySave)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	backend := &scriptedBackend{reply: func(req *Request, n int) (*Response, error) {
+		return &Response{
+			Text:      `{"type": "feat", "subject": "add a cache"}`,
+			Reasoning: "the diff adds a cache",
+			Usage:     Usage{PromptTokens: 120, CompletionTokens: 30},
+		}, nil
+	}}
+	req := &Request{System: "SYSTEM", Prompt: "PROMPT"}
+
+	recorded, err := NewRecordingBackend(backend, dir).Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fixturePath(dir, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"reasoning": "the diff adds a cache"`) || !strings.Contains(string(data), `"backend": "scripted"`) {
+		t.Errorf("fixture does not record the reasoning and the backend:\n%s", data)
+	}
+
+	var streamed strings.Builder
+	replayReq := &Request{System: "SYSTEM", Prompt: "PROMPT", Stream: func(text string) { streamed.WriteString(text) }}
+	replayed, err := NewReplayBackend(dir).Generate(context.Background(), replayReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *replayed != *recorded {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if streamed.String() != recorded.Text {
+		t.Errorf("streamed %q", streamed.String())
+	}
+
+	// 提示词不同时没有回放数据
+	_, err = NewReplayBackend(dir).Generate(context.Background(), &Request{System: "SYSTEM", Prompt: "OTHER"})
+	if i18n.CodeOf(err) != i18n.AIReplayNotFound {
+		t.Errorf("want %s, got %v", i18n.AIReplayNotFound, err)
+	}
+}
@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// stderrTailSize 是出错时保留的标准错误输出的最大字节数
+const stderrTailSize = 4096
+
+// stopScanner 在流式输出中查找终止标记，终止标记可能跨越多行或被拆分在多个块中。
+// 可能是终止标记开头的内容会暂时保留，确认不是终止标记后再输出，
+// 因此终止标记本身不会出现在输出中
+type stopScanner struct {
+	stops   []string
+	emit    func(string) // 输出确认不属于终止标记的内容
+	pending string       // 尚未输出的内容
+	stopped bool         // 是否已经遇到终止标记
+}
+
+// newStopScanner 创建终止标记扫描器
+func newStopScanner(stops []string, emit func(string)) *stopScanner {
+	nonEmpty := []string{}
+	for _, stop := range stops {
+		if stop != "" {
+			nonEmpty = append(nonEmpty, stop)
+		}
+	}
+	return &stopScanner{stops: nonEmpty, emit: emit}
+}
+
+// Write 处理一块输出，遇到终止标记时返回true，之后的内容都会被丢弃
+func (s *stopScanner) Write(chunk string) bool {
+	if s.stopped {
+		return true
+	}
+	s.pending += chunk
+
+	// 查找最早出现的终止标记
+	index := -1
+	for _, stop := range s.stops {
+		if i := strings.Index(s.pending, stop); i != -1 && (index == -1 || i < index) {
+			index = i
+		}
+	}
+	if index != -1 {
+		s.output(s.pending[:index])
+		s.pending = ""
+		s.stopped = true
+		return true
+	}
+
+	// 保留可能是终止标记开头的结尾部分，以及被拆开的多字节字符
+	keep := s.partialStopLength()
+	if n := incompleteRuneLength(s.pending[:len(s.pending)-keep]); n > 0 {
+		keep += n
+	}
+	s.output(s.pending[:len(s.pending)-keep])
+	s.pending = s.pending[len(s.pending)-keep:]
+	return false
+}
+
+// Flush 在输出结束时输出保留的内容
+func (s *stopScanner) Flush() {
+	if !s.stopped {
+		s.output(s.pending)
+	}
+	s.pending = ""
+}
+
+// output 输出非空内容
+func (s *stopScanner) output(text string) {
+	if text != "" {
+		s.emit(text)
+	}
+}
+
+// partialStopLength 返回pending结尾与某个终止标记开头重合的最大长度
+func (s *stopScanner) partialStopLength() int {
+	longest := 0
+	for _, stop := range s.stops {
+		for n := len(stop) - 1; n > longest; n-- {
+			if strings.HasSuffix(s.pending, stop[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// incompleteRuneLength 返回text结尾不完整的UTF-8字符的字节数
+func incompleteRuneLength(text string) int {
+	for n := 1; n < utf8.UTFMax && n <= len(text); n++ {
+		if utf8.RuneStart(text[len(text)-n]) {
+			if !utf8.FullRuneInString(text[len(text)-n:]) {
+				return n
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// tailBuffer 只保留最后size个字节的写入内容
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	data []byte
+}
+
+// Write 追加内容，超出容量时丢弃最早的部分
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.size {
+		b.data = append([]byte(nil), b.data[len(b.data)-b.size:]...)
+	}
+	return len(p), nil
+}
+
+// String 返回保留的内容
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.data))
+}
+
+// runProcess 运行子进程并读取标准输出：按块实时输出，遇到终止标记时结束整个进程组。
+// 标准错误输出的结尾部分会在进程异常退出时附加到错误中，debug不为nil时同时写入debug。
+// 出错时同样返回已经读取到的输出
+func runProcess(ctx context.Context, cmd *exec.Cmd, stops []string, stream func(string), debug io.Writer) (string, error) {
+	name := filepath.Base(cmd.Path)
+	stderr := &tailBuffer{size: stderrTailSize}
+	if debug != nil {
+		cmd.Stderr = io.MultiWriter(stderr, debug)
+	} else {
+		cmd.Stderr = stderr
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.AICreatePipe)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", i18n.Wrap(err, i18n.AIProcessStart, name)
+	}
+
+	var output strings.Builder
+	scanner := newStopScanner(stops, func(text string) {
+		output.WriteString(text)
+		if stream != nil {
+			stream(text)
+		}
+	})
+
+	// 在当前goroutine中读取输出，读取结束后才等待进程退出，避免与cmd.Wait竞争
+	stopped := false
+	buf := make([]byte, 4096)
+	var readErr error
+	for {
+		n, err := stdout.Read(buf)
+		if n > 0 && scanner.Write(string(buf[:n])) {
+			stopped = true
+			killProcessGroup(cmd)
+			break
+		}
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+	}
+	scanner.Flush()
+
+	waitErr := cmd.Wait()
+	text := output.String()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return text, i18n.New(i18n.AIProcessTimeout, name)
+	case ctx.Err() != nil:
+		return text, ctx.Err()
+	case stopped:
+		// 进程是因为遇到终止标记而被结束的
+		return text, nil
+	case waitErr != nil:
+		var exitErr *exec.ExitError
+		if errors.As(waitErr, &exitErr) && stderr.String() != "" {
+			return text, i18n.Wrap(fmt.Errorf("%w: %s", waitErr, stderr.String()), i18n.AIProcessExit, name)
+		}
+		return text, i18n.Wrap(waitErr, i18n.AIProcessExit, name)
+	case readErr != nil:
+		return text, i18n.Wrap(readErr, i18n.AIProcessRead, name)
+	}
+	return text, nil
+}
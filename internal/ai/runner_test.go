@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+func TestStopScanner(t *testing.T) {
+	tests := []struct {
+		name    string
+		stops   []string
+		chunks  []string
+		want    string
+		stopped bool
+	}{
+		{
+			name:    "marker in one chunk",
+			stops:   []string{"<|im_end|>"},
+			chunks:  []string{`{"type": "fix"}<|im_end|>garbage`},
+			want:    `{"type": "fix"}`,
+			stopped: true,
+		},
+		{
+			name:    "marker split across chunks",
+			stops:   []string{"<|im_end|>"},
+			chunks:  []string{`{"type"`, `: "fix"}<|im`, "_e", "nd|>", "garbage"},
+			want:    `{"type": "fix"}`,
+			stopped: true,
+		},
+		{
+			name:    "marker split into single bytes",
+			stops:   []string{"[end of text]"},
+			chunks:  strings.Split("ok[end of text]after", ""),
+			want:    "ok",
+			stopped: true,
+		},
+		{
+			name:   "partial marker at EOF",
+			stops:  []string{"<|im_end|>"},
+			chunks: []string{"done", "<|im_"},
+			want:   "done<|im_",
+		},
+		{
+			name:   "partial marker followed by other text",
+			stops:  []string{"<|im_end|>"},
+			chunks: []string{"a <|im", "_start|> b"},
+			want:   "a <|im_start|> b",
+		},
+		{
+			name:    "several markers, earliest wins",
+			stops:   []string{"<|im_end|>", "<|endoftext|>"},
+			chunks:  []string{"text<|endof", "text|>more<|im_end|>"},
+			want:    "text",
+			stopped: true,
+		},
+		{
+			name:    "marker that is a prefix of another",
+			stops:   []string{"</s>", "</s></s>"},
+			chunks:  []string{"x</", "s>y"},
+			want:    "x",
+			stopped: true,
+		},
+		{
+			name:    "marker across lines",
+			stops:   []string{"\n\n> "},
+			chunks:  []string{"line\n", "\n", "> next"},
+			want:    "line",
+			stopped: true,
+		},
+		{
+			name:   "multibyte rune split across chunks",
+			stops:  []string{"<|im_end|>"},
+			chunks: []string{"修复\xe7\xbc", "\x93存"},
+			want:   "修复缓存",
+		},
+		{
+			name:   "empty markers are ignored",
+			stops:  []string{"", "<|im_end|>"},
+			chunks: []string{"a", "b"},
+			want:   "ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var emitted []string
+			scanner := newStopScanner(tt.stops, func(text string) {
+				emitted = append(emitted, text)
+			})
+			stopped := false
+			for _, chunk := range tt.chunks {
+				if scanner.Write(chunk) {
+					stopped = true
+				}
+			}
+			scanner.Flush()
+
+			if got := strings.Join(emitted, ""); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if stopped != tt.stopped {
+				t.Errorf("got stopped %v, want %v", stopped, tt.stopped)
+			}
+			// 输出的每一块都是完整的UTF-8
+			for _, text := range emitted {
+				if !utf8.ValidString(text) {
+					t.Errorf("emitted invalid UTF-8 %q", text)
+				}
+			}
+		})
+	}
+}
+
+func TestStopScannerDiscardsAfterStop(t *testing.T) {
+	var got strings.Builder
+	scanner := newStopScanner([]string{"<|im_end|>"}, func(text string) { got.WriteString(text) })
+	scanner.Write("a<|im_end|>")
+	if !scanner.Write("b") {
+		t.Error("Write after the marker should report stopped")
+	}
+	scanner.Flush()
+	if got.String() != "a" {
+		t.Errorf("got %q, want %q", got.String(), "a")
+	}
+}
+
+func TestRunProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	t.Run("kills the process after the marker", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		// sh之后的sleep不会自行退出，只有结束整个进程组后才能读到EOF
+		cmd := exec.CommandContext(ctx, "sh", "-c", `printf 'hello <|im'; sleep 0.1; printf '_end|>garbage'; sleep 60`)
+		setProcessGroup(cmd)
+		var streamed strings.Builder
+		text, err := runProcess(ctx, cmd, []string{"<|im_end|>"}, func(s string) { streamed.WriteString(s) }, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "hello " || streamed.String() != "hello " {
+			t.Errorf("got text %q, streamed %q", text, streamed.String())
+		}
+	})
+
+	t.Run("reports stderr on failure", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", `printf 'partial'; echo 'failed to load model' >&2; exit 3`)
+		text, err := runProcess(context.Background(), cmd, []string{"<|im_end|>"}, nil, nil)
+		if i18n.CodeOf(err) != i18n.AIProcessExit || !strings.Contains(err.Error(), "failed to load model") {
+			t.Errorf("want %s with stderr, got %v", i18n.AIProcessExit, err)
+		}
+		if text != "partial" {
+			t.Errorf("got text %q, want the partial output", text)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 约束解码模式
+const (
+	ConstrainNone       = "none"        // 不约束输出
+	ConstrainJSONSchema = "json-schema" // 使用JSON Schema约束输出
+	ConstrainGrammar    = "grammar"     // 使用GBNF语法约束输出
+)
+
+// CommitTypes 是允许的约定式提交类型
+var CommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// schemaField 表示CommitMessage中参与生成的一个字段
+type schemaField struct {
+	name string       // JSON字段名
+	kind reflect.Kind // 字段类型
+}
+
+// commitMessageFields 通过反射列出CommitMessage中需要模型生成的字段，顺序与结构体定义一致
+func commitMessageFields() []schemaField {
+	t := reflect.TypeOf(CommitMessage{})
+	fields := []schemaField{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, schemaField{name: name, kind: t.Field(i).Type.Kind()})
+	}
+	return fields
+}
+
+// CommitMessageSchema 根据CommitMessage结构体生成JSON Schema，type字段限定为types，
+// scopes不为空时scope字段限定为空字符串或scopes之一
+func CommitMessageSchema(types, scopes []string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, field := range commitMessageFields() {
+		var prop map[string]interface{}
+		switch {
+		case field.name == "type":
+			prop = map[string]interface{}{"type": "string", "enum": types}
+		case field.name == "scope" && len(scopes) > 0:
+			prop = map[string]interface{}{"type": "string", "enum": append([]string{""}, scopes...)}
+		case field.kind == reflect.Bool:
+			prop = map[string]interface{}{"type": "boolean"}
+		default:
+			prop = map[string]interface{}{"type": "string"}
+		}
+		properties[field.name] = prop
+		required = append(required, field.name)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// CommitMessageSchemaJSON 返回序列化后的JSON Schema
+func CommitMessageSchemaJSON(types, scopes []string) string {
+	data, _ := json.Marshal(CommitMessageSchema(types, scopes))
+	return string(data)
+}
+
+// CommitMessageGrammar 根据CommitMessage结构体生成llama.cpp的GBNF语法，type字段限定为types，
+// scopes不为空时scope字段限定为空字符串或scopes之一
+func CommitMessageGrammar(types, scopes []string) string {
+	var sb strings.Builder
+
+	sb.WriteString(`root ::= "{" ws `)
+	for i, field := range commitMessageFields() {
+		if i > 0 {
+			sb.WriteString(`"," ws `)
+		}
+		rule := "string"
+		switch {
+		case field.name == "type":
+			rule = "commit-type"
+		case field.name == "scope" && len(scopes) > 0:
+			rule = "commit-scope"
+		case field.kind == reflect.Bool:
+			rule = "boolean"
+		}
+		sb.WriteString(fmt.Sprintf(`"\"%s\"" ws ":" ws %s ws `, field.name, rule))
+	}
+	sb.WriteString("\"}\"\n")
+
+	sb.WriteString("commit-type ::= " + grammarAlternatives(types) + "\n")
+	if len(scopes) > 0 {
+		sb.WriteString("commit-scope ::= " + grammarAlternatives(append([]string{""}, scopes...)) + "\n")
+	}
+	sb.WriteString(`string ::= "\"" ( [^"\\\x7F\x00-\x1F] | "\\" ( ["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] ) )* "\""` + "\n")
+	sb.WriteString(`boolean ::= "true" | "false"` + "\n")
+	sb.WriteString(`ws ::= [ \t\n]{0,20}` + "\n")
+
+	return sb.String()
+}
+
+// grammarAlternatives 返回匹配values中任意一个JSON字符串的GBNF规则
+func grammarAlternatives(values []string) string {
+	alternatives := make([]string, 0, len(values))
+	for _, v := range values {
+		alternatives = append(alternatives, grammarLiteral(jsonString(v)))
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+// grammarLiteral 返回匹配s的GBNF字符串字面量，转义其中的"和\
+func grammarLiteral(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// jsonString 返回s编码后的JSON字符串，不转义HTML字符
+func jsonString(s string) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
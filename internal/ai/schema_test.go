@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommitMessageSchema(t *testing.T) {
+	schema := CommitMessageSchema([]string{"feat", "fix"}, []string{"api", "cli"})
+	// 经过序列化，与发送给后端的内容一致
+	var got struct {
+		Type                 string                            `json:"type"`
+		Properties           map[string]map[string]interface{} `json:"properties"`
+		Required             []string                          `json:"required"`
+		AdditionalProperties bool                              `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal([]byte(CommitMessageSchemaJSON([]string{"feat", "fix"}, []string{"api", "cli"})), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Required, commitMessageFieldNames()) || got.Type != "object" || got.AdditionalProperties {
+		t.Errorf("unexpected schema: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Required, []string{"subject", "body", "type", "scope", "breaking_changes"}) {
+		t.Errorf("required: got %v", got.Required)
+	}
+	for field, want := range map[string]map[string]interface{}{
+		"type":             {"type": "string", "enum": []interface{}{"feat", "fix"}},
+		"scope":            {"type": "string", "enum": []interface{}{"", "api", "cli"}},
+		"subject":          {"type": "string"},
+		"breaking_changes": {"type": "boolean"},
+	} {
+		if !reflect.DeepEqual(got.Properties[field], want) {
+			t.Errorf("%s: got %v, want %v", field, got.Properties[field], want)
+		}
+	}
+	if len(schema["properties"].(map[string]interface{})) != len(got.Required) {
+		t.Errorf("every property should be required: %v", schema)
+	}
+
+	// 没有限定scope时不使用enum
+	schema = CommitMessageSchema([]string{"feat"}, nil)
+	if scope := schema["properties"].(map[string]interface{})["scope"]; !reflect.DeepEqual(scope, map[string]interface{}{"type": "string"}) {
+		t.Errorf("scope without scopes: got %v", scope)
+	}
+}
+
+func TestCommitMessageGrammar(t *testing.T) {
+	grammar := CommitMessageGrammar([]string{"feat", "fix"}, []string{"api"})
+	for _, want := range []string{
+		`commit-type ::= "\"feat\"" | "\"fix\""`,
+		`commit-scope ::= "\"\"" | "\"api\""`,
+		`"\"type\"" ws ":" ws commit-type`,
+		`"\"scope\"" ws ":" ws commit-scope`,
+		`"\"subject\"" ws ":" ws string`,
+		`"\"body\"" ws ":" ws string`,
+		`"\"breaking_changes\"" ws ":" ws boolean`,
+	} {
+		if !strings.Contains(grammar, want) {
+			t.Errorf("grammar does not contain %s:\n%s", want, grammar)
+		}
+	}
+	if !strings.HasPrefix(grammar, `root ::= "{" ws "\"subject\""`) {
+		t.Errorf("fields are out of order:\n%s", grammar)
+	}
+
+	grammar = CommitMessageGrammar([]string{"feat"}, nil)
+	if strings.Contains(grammar, "commit-scope") || !strings.Contains(grammar, `"\"scope\"" ws ":" ws string`) {
+		t.Errorf("scope without scopes should be any string:\n%s", grammar)
+	}
+}
+
+func TestGrammarAlternativesEscape(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "feat", want: `"\"feat\""`},
+		{value: `say "hi"`, want: `"\"say \\\"hi\\\"\""`},
+		{value: `a\b`, want: `"\"a\\\\b\""`},
+		{value: "<ui>", want: `"\"<ui>\""`},
+	}
+	for _, tt := range tests {
+		if got := grammarAlternatives([]string{tt.value}); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+	if got := grammarAlternatives([]string{"a", "b"}); got != `"\"a\"" | "\"b\""` {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// ChatTemplate 描述一个模型家族的对话模板，用于需要由aimmit拼接完整提示词的后端
+type ChatTemplate struct {
+	Name         string   // 模板名称
+	Format       string   // 提示词格式，依次填入系统提示和用户提示
+	SystemSuffix string   // 追加在系统提示之后的内容，例如Qwen3的/no_think
+	ThinkSuffix  string   // 思考模式下代替SystemSuffix追加的内容，例如Qwen3的/think
+	Stop         []string // 终止标记
+	NoSystemRole bool     // 模板没有system角色时，系统提示会并入用户提示
+}
+
+// Render 按模板拼接完整提示词
+func (t *ChatTemplate) Render(req *Request) string {
+	suffix := t.SystemSuffix
+	if req.Options.Think {
+		suffix = t.ThinkSuffix
+	}
+	system := req.System + suffix
+	if t.NoSystemRole {
+		return fmt.Sprintf(t.Format, system+"\n\n"+req.Prompt)
+	}
+	return fmt.Sprintf(t.Format, system, req.Prompt)
+}
+
+// chatTemplates 是内置的对话模板，按模型家族索引
+var chatTemplates = map[string]*ChatTemplate{
+	"qwen3": {
+		Name:         "qwen3",
+		Format:       "<|im_start|>system\n%s<|im_end|>\n<|im_start|>user\n%s<|im_end|>\n<|im_start|>assistant\n",
+		SystemSuffix: "/no_think",
+		ThinkSuffix:  "/think",
+		Stop:         []string{"<|im_end|>", "<|endoftext|>"},
+	},
+	"chatml": {
+		Name:   "chatml",
+		Format: "<|im_start|>system\n%s<|im_end|>\n<|im_start|>user\n%s<|im_end|>\n<|im_start|>assistant\n",
+		Stop:   []string{"<|im_end|>", "<|endoftext|>"},
+	},
+	"llama3": {
+		Name:   "llama3",
+		Format: "<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\n%s<|eot_id|><|start_header_id|>user<|end_header_id|>\n\n%s<|eot_id|><|start_header_id|>assistant<|end_header_id|>\n\n",
+		Stop:   []string{"<|eot_id|>", "<|end_of_text|>"},
+	},
+	"mistral": {
+		Name:         "mistral",
+		Format:       "<s>[INST] %s [/INST]",
+		Stop:         []string{"</s>"},
+		NoSystemRole: true,
+	},
+	"gemma": {
+		Name:         "gemma",
+		Format:       "<start_of_turn>user\n%s<end_of_turn>\n<start_of_turn>model\n",
+		Stop:         []string{"<end_of_turn>", "<eos>"},
+		NoSystemRole: true,
+	},
+	"phi3": {
+		Name:   "phi3",
+		Format: "<|system|>\n%s<|end|>\n<|user|>\n%s<|end|>\n<|assistant|>\n",
+		Stop:   []string{"<|end|>", "<|endoftext|>"},
+	},
+}
+
+// DefaultChatTemplate 是无法识别模型家族时使用的模板
+const DefaultChatTemplate = "qwen3"
+
+// templateFamilies 把模型名称中的关键字映射到模板，按顺序匹配，更具体的关键字在前
+var templateFamilies = []struct {
+	keyword  string
+	template string
+}{
+	{"qwen3", "qwen3"},
+	{"qwen", "chatml"},
+	{"llama-3", "llama3"},
+	{"llama3", "llama3"},
+	{"mistral", "mistral"},
+	{"mixtral", "mistral"},
+	{"gemma", "gemma"},
+	{"phi-3", "phi3"},
+	{"phi3", "phi3"},
+	{"phi-4", "phi3"},
+	{"phi4", "phi3"},
+}
+
+// ChatTemplateNames 返回所有内置模板名称
+func ChatTemplateNames() []string {
+	names := make([]string, 0, len(chatTemplates))
+	for name := range chatTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetChatTemplate 按名称获取内置模板
+func GetChatTemplate(name string) (*ChatTemplate, error) {
+	t, ok := chatTemplates[strings.ToLower(name)]
+	if !ok {
+		return nil, i18n.New(i18n.AIUnsupportedTemplate, name, strings.Join(ChatTemplateNames(), ", "))
+	}
+	return t, nil
+}
+
+// DetectChatTemplate 根据模型名称或模型文件名识别模型家族，返回模板名称，无法识别时返回空字符串
+func DetectChatTemplate(model string) string {
+	model = strings.ToLower(filepath.Base(model))
+	for _, family := range templateFamilies {
+		if strings.Contains(model, family.keyword) {
+			return family.template
+		}
+	}
+	return ""
+}
+
+// jinjaMarkers 把GGUF内置Jinja模板中的特征标记映射到模板
+var jinjaMarkers = []struct {
+	marker   string
+	template string
+}{
+	{"<|start_header_id|>", "llama3"},
+	{"<start_of_turn>", "gemma"},
+	{"[INST]", "mistral"},
+	{"<|assistant|>", "phi3"},
+	{"<|im_start|>", "chatml"},
+}
+
+// archTemplates 把GGUF中的general.architecture映射到模板
+var archTemplates = map[string]string{
+	"qwen3":    "qwen3",
+	"qwen3moe": "qwen3",
+	"qwen2":    "chatml",
+	"llama":    "llama3",
+	"gemma":    "gemma",
+	"gemma2":   "gemma",
+	"gemma3":   "gemma",
+	"phi3":     "phi3",
+}
+
+// DetectChatTemplateFromGGUF 根据GGUF元数据中的架构和内置Jinja模板识别模板名称，无法识别时返回空字符串
+func DetectChatTemplateFromGGUF(architecture, jinja string) string {
+	// Qwen3的Jinja模板同样是ChatML，但需要额外的/no_think，只能按架构识别
+	if name := archTemplates[architecture]; name == "qwen3" {
+		return name
+	}
+	// Mistral等模型的架构也是llama，优先根据Jinja模板的特征识别
+	for _, m := range jinjaMarkers {
+		if strings.Contains(jinja, m.marker) {
+			return m.template
+		}
+	}
+	return archTemplates[architecture]
+}
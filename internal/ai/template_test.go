@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// GGUF中内置的Jinja对话模板片段
+const (
+	chatMLJinja  = "{% for message in messages %}{{'<|im_start|>' + message['role'] + '\\n' + message['content'] + '<|im_end|>' + '\\n'}}{% endfor %}{% if add_generation_prompt %}{{ '<|im_start|>assistant\\n' }}{% endif %}"
+	llama3Jinja  = "{{- bos_token }}{%- for message in messages %}{{- '<|start_header_id|>' + message['role'] + '<|end_header_id|>\\n\\n'+ message['content'] | trim + '<|eot_id|>' }}{%- endfor %}"
+	mistralJinja = "{{ bos_token }}{% for message in messages %}{% if message['role'] == 'user' %}{{ '[INST] ' + message['content'] + ' [/INST]' }}{% else %}{{ message['content'] + eos_token}}{% endif %}{% endfor %}"
+	gemmaJinja   = "{{ bos_token }}{% for message in messages %}{{ '<start_of_turn>' + role + '\\n' + message['content'] | trim + '<end_of_turn>\\n' }}{% endfor %}{% if add_generation_prompt %}{{'<start_of_turn>model\\n'}}{% endif %}"
+	phi3Jinja    = "{% for message in messages %}{% if message['role'] == 'system' %}{{'<|system|>\\n' + message['content'] + '<|end|>\\n'}}{% elif message['role'] == 'user' %}{{'<|user|>\\n' + message['content'] + '<|end|>\\n'}}{% endif %}{% endfor %}{{ '<|assistant|>\\n' }}"
+)
+
+func TestDetectChatTemplateFromGGUF(t *testing.T) {
+	tests := []struct {
+		name         string
+		architecture string
+		jinja        string
+		want         string
+		stop         []string
+		prefix       string // 拼接后的提示词开头
+	}{
+		{name: "qwen3", architecture: "qwen3", jinja: chatMLJinja, want: "qwen3", stop: []string{"<|im_end|>", "<|endoftext|>"}, prefix: "<|im_start|>system\nSYSTEM/no_think<|im_end|>"},
+		{name: "qwen2.5", architecture: "qwen2", jinja: chatMLJinja, want: "chatml", stop: []string{"<|im_end|>", "<|endoftext|>"}, prefix: "<|im_start|>system\nSYSTEM<|im_end|>"},
+		{name: "llama3", architecture: "llama", jinja: llama3Jinja, want: "llama3", stop: []string{"<|eot_id|>", "<|end_of_text|>"}, prefix: "<|begin_of_text|><|start_header_id|>system"},
+		{name: "mistral with llama architecture", architecture: "llama", jinja: mistralJinja, want: "mistral", stop: []string{"</s>"}, prefix: "<s>[INST] SYSTEM\n\nPROMPT [/INST]"},
+		{name: "gemma", architecture: "gemma2", jinja: gemmaJinja, want: "gemma", stop: []string{"<end_of_turn>", "<eos>"}, prefix: "<start_of_turn>user\nSYSTEM\n\nPROMPT<end_of_turn>"},
+		{name: "phi3", architecture: "phi3", jinja: phi3Jinja, want: "phi3", stop: []string{"<|end|>", "<|endoftext|>"}, prefix: "<|system|>\nSYSTEM<|end|>"},
+		{name: "no jinja template", architecture: "gemma3", want: "gemma", stop: []string{"<end_of_turn>", "<eos>"}, prefix: "<start_of_turn>user\n"},
+		{name: "unknown", architecture: "rwkv", jinja: "{{ messages }}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectChatTemplateFromGGUF(tt.architecture, tt.jinja)
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			if tt.want == "" {
+				return
+			}
+			tmpl, err := GetChatTemplate(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tmpl.Stop, tt.stop) {
+				t.Errorf("stop: got %q, want %q", tmpl.Stop, tt.stop)
+			}
+			prompt := tmpl.Render(&Request{System: "SYSTEM", Prompt: "PROMPT"})
+			if !strings.HasPrefix(prompt, tt.prefix) || !strings.Contains(prompt, "PROMPT") {
+				t.Errorf("rendered prompt %q does not start with %q", prompt, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestDetectChatTemplate(t *testing.T) {
+	for model, want := range map[string]string{
+		"model/Qwen3-1.7B-Q6_K.gguf":             "qwen3",
+		"qwen2.5-coder:7b":                       "chatml",
+		"Meta-Llama-3.1-8B-Instruct-Q4_K_M.gguf": "llama3",
+		"mixtral-8x7b":                           "mistral",
+		"gemma-2-2b-it":                          "gemma",
+		"Phi-4-mini":                             "phi3",
+		"unknown-model.gguf":                     "",
+	} {
+		if got := DetectChatTemplate(model); got != want {
+			t.Errorf("%s: got %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestChatTemplateRenderThink(t *testing.T) {
+	tmpl, _ := GetChatTemplate("Qwen3")
+	req := &Request{System: "SYSTEM", Prompt: "PROMPT", Options: Options{Think: true}}
+	if prompt := tmpl.Render(req); !strings.Contains(prompt, "SYSTEM/think<|im_end|>") {
+		t.Errorf("think mode should use /think:\n%s", prompt)
+	}
+	if _, err := GetChatTemplate("vicuna"); i18n.CodeOf(err) != i18n.AIUnsupportedTemplate {
+		t.Errorf("want %s, got %v", i18n.AIUnsupportedTemplate, err)
+	}
+}
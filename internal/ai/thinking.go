@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"strings"
+)
+
+// 推理内容的起止标记（Qwen3、DeepSeek-R1等模型）
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// defaultThinkTokens 是思考模式下默认为推理内容额外预留的token数
+const defaultThinkTokens = 1024
+
+// SetThink 设置是否允许模型在回答前先推理（思考模式）
+func (c *Client) SetThink(think bool) {
+	c.think = think
+}
+
+// SetThinkTokens 设置思考模式下为推理内容额外预留的token数
+func (c *Client) SetThinkTokens(tokens int) {
+	c.thinkTokens = tokens
+}
+
+// SetShowThinking 设置是否显示模型的推理内容：debug模式下输出完整的推理内容，并在实时输出中保留推理过程
+func (c *Client) SetShowThinking(show bool) {
+	c.showThinking = show
+}
+
+// generationTokens 返回单次生成最多的token数，思考模式下包含推理内容
+func (c *Client) generationTokens() int {
+	if c.think {
+		return c.maxTokens + c.thinkTokens
+	}
+	return c.maxTokens
+}
+
+// splitThinking 把回复拆分为推理内容和回答，去掉所有<think>...</think>块。
+// 没有结束标记的推理（例如生成被截断）全部作为推理内容；
+// 对话模板已经写入开始标记时，回复中只有结束标记，之前的内容都是推理内容
+func splitThinking(text string) (reasoning, answer string) {
+	var thoughts, rest strings.Builder
+	if close := strings.Index(text, thinkClose); close != -1 && !strings.Contains(text[:close], thinkOpen) {
+		thoughts.WriteString(strings.TrimSpace(text[:close]))
+		text = text[close+len(thinkClose):]
+	}
+	for {
+		open := strings.Index(text, thinkOpen)
+		if open == -1 {
+			rest.WriteString(text)
+			break
+		}
+		rest.WriteString(text[:open])
+		text = text[open+len(thinkOpen):]
+
+		close := strings.Index(text, thinkClose)
+		if close == -1 {
+			close = len(text)
+		}
+		if thought := strings.TrimSpace(text[:close]); thought != "" {
+			if thoughts.Len() > 0 {
+				thoughts.WriteString("\n")
+			}
+			thoughts.WriteString(thought)
+		}
+		if close == len(text) {
+			break
+		}
+		text = text[close+len(thinkClose):]
+	}
+	return thoughts.String(), strings.TrimSpace(rest.String())
+}
+
+// thinkFilter 从流式输出中去掉<think>...</think>块，标记可能被拆分在多个块中。
+// 与splitThinking相同，第一个标记是结束标记时，之前的内容都是推理内容，
+// 所以出现第一个标记之前的内容暂不输出
+type thinkFilter struct {
+	emit     func(string) // 输出推理内容以外的部分
+	started  bool         // 是否已经出现过标记
+	inThink  bool         // 当前是否在推理内容中
+	pending  string       // 可能是标记开头、尚未输出的内容
+	answered bool         // 是否已经输出过回答，用于去掉推理块之后的空行
+}
+
+// newThinkFilter 创建推理内容过滤器
+func newThinkFilter(emit func(string)) *thinkFilter {
+	return &thinkFilter{emit: emit}
+}
+
+// Write 处理一块流式输出
+func (f *thinkFilter) Write(chunk string) {
+	f.pending += chunk
+	if !f.started {
+		open := strings.Index(f.pending, thinkOpen)
+		close := strings.Index(f.pending, thinkClose)
+		switch {
+		case close != -1 && (open == -1 || close < open):
+			// 对话模板已经写入开始标记
+			f.pending = f.pending[close+len(thinkClose):]
+		case open == -1:
+			return
+		}
+		f.started = true
+	}
+	for {
+		tag := thinkOpen
+		if f.inThink {
+			tag = thinkClose
+		}
+		if i := strings.Index(f.pending, tag); i != -1 {
+			if !f.inThink {
+				f.output(f.pending[:i])
+			}
+			f.pending = f.pending[i+len(tag):]
+			f.inThink = !f.inThink
+			continue
+		}
+
+		keep := partialSuffix(f.pending, tag)
+		if !f.inThink {
+			f.output(f.pending[:len(f.pending)-keep])
+		}
+		f.pending = f.pending[len(f.pending)-keep:]
+		return
+	}
+}
+
+// Flush 在输出结束时输出保留的内容
+func (f *thinkFilter) Flush() {
+	if !f.inThink {
+		f.output(f.pending)
+	}
+	f.pending = ""
+}
+
+// output 输出回答，去掉回答开头的空白
+func (f *thinkFilter) output(text string) {
+	if !f.answered {
+		text = strings.TrimLeft(text, " \t\r\n")
+	}
+	if text != "" {
+		f.answered = true
+		f.emit(text)
+	}
+}
+
+// partialSuffix 返回text结尾与tag开头重合的最大长度
+func partialSuffix(text, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// withThinking 允许模型在GBNF语法约束的回答之前输出一个<think>...</think>块
+func withThinking(grammar string) string {
+	grammar = strings.Replace(grammar, "root ::=", "answer ::=", 1)
+	return `root ::= think? answer` + "\n" +
+		`think ::= "<think>" ( [^<] | "<" [^/] )* "</think>" ws` + "\n" +
+		grammar
+}
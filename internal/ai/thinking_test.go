@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitThinking(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		reasoning string
+		answer    string
+	}{
+		{name: "no reasoning", text: ` {"type": "feat"} `, answer: `{"type": "feat"}`},
+		{name: "think block", text: "<think>\nthe diff adds a cache\n</think>\n\n{}", reasoning: "the diff adds a cache", answer: "{}"},
+		{name: "several blocks", text: "<think>a</think>{<think> b </think>}", reasoning: "a\nb", answer: "{}"},
+		{name: "empty block", text: "<think>\n\n</think>{}", answer: "{}"},
+		{name: "unclosed block", text: "{}<think>truncated", reasoning: "truncated", answer: "{}"},
+		{name: "leading close tag", text: "opened by the template\n</think>\n{}", reasoning: "opened by the template", answer: "{}"},
+		{name: "leading close tag then block", text: "first</think>{<think>second</think>}", reasoning: "first\nsecond", answer: "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasoning, answer := splitThinking(tt.text)
+			if reasoning != tt.reasoning || answer != tt.answer {
+				t.Errorf("got (%q, %q), want (%q, %q)", reasoning, answer, tt.reasoning, tt.answer)
+			}
+		})
+	}
+}
+
+func TestThinkFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{name: "no reasoning", chunks: []string{`{"type"`, `: "feat"}`}, want: `{"type": "feat"}`},
+		{name: "think block", chunks: []string{"<think>reasoning</think>\n\n", "{}"}, want: "{}"},
+		{name: "tags split across chunks", chunks: []string{"<thi", "nk>reason", "ing</th", "ink>", "\n{", "}"}, want: "{}"},
+		{name: "tag split into single bytes", chunks: strings.Split("<think>x</think>{}", ""), want: "{}"},
+		{name: "less-than sign in the answer", chunks: []string{"<think>x</think>{\"subject\": \"a <", " b\"}"}, want: "{\"subject\": \"a < b\"}"},
+		{name: "block after the answer starts", chunks: []string{"{", "<think>x</think>", "}"}, want: "{}"},
+		{name: "unclosed block", chunks: []string{"{}", "<think>trunc", "ated"}, want: "{}"},
+		{name: "leading close tag", chunks: []string{"opened by ", "the template</thi", "nk>\n{}"}, want: "{}"},
+		{name: "leading close tag then block", chunks: []string{"first</think>{<think>second</think>}"}, want: "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			filter := newThinkFilter(func(text string) { out.WriteString(text) })
+			for _, chunk := range tt.chunks {
+				filter.Write(chunk)
+			}
+			filter.Flush()
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+			// 与一次性拆分的结果一致
+			if _, answer := splitThinking(strings.Join(tt.chunks, "")); answer != tt.want {
+				t.Errorf("splitThinking: got %q, want %q", answer, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartialSuffix(t *testing.T) {
+	for text, want := range map[string]int{"": 0, "abc": 0, "abc<": 1, "abc<th": 3, "<think": 6, "<think>": 0, "</": 0} {
+		if got := partialSuffix(text, thinkOpen); got != want {
+			t.Errorf("%q: got %d, want %d", text, got, want)
+		}
+	}
+	if got := partialSuffix("abc</thin", thinkClose); got != 6 {
+		t.Errorf("close tag: got %d, want 6", got)
+	}
+}
+
+func TestWithThinking(t *testing.T) {
+	grammar := CommitMessageGrammar([]string{"feat", "fix"}, nil)
+	got := withThinking(grammar)
+	if !strings.HasPrefix(got, "root ::= think? answer\n") {
+		t.Errorf("root rule does not allow a think block:\n%s", got)
+	}
+	if strings.Count(got, "root ::=") != 1 || !strings.Contains(got, "answer ::=") {
+		t.Errorf("the original root rule should be renamed to answer:\n%s", got)
+	}
+	if !strings.Contains(got, `think ::= "<think>"`) || !strings.Contains(got, `"</think>" ws`) {
+		t.Errorf("missing the think rule:\n%s", got)
+	}
+	if !strings.HasSuffix(got, strings.Replace(grammar, "root ::=", "answer ::=", 1)) {
+		t.Errorf("the rest of the grammar should be kept:\n%s", got)
+	}
+}
@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rust17/AImmit/internal/git"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// defaultContextSize 是无法获知模型上下文长度时假定的值（llama.cpp的默认值）
+const defaultContextSize = 4096
+
+// templateOverhead 是对话模板的特殊标记等额外占用的token数
+const templateOverhead = 64
+
+// Tokenizer 由可以统计真实token数的后端实现
+type Tokenizer interface {
+	// CountTokens 返回text对应的token数
+	CountTokens(ctx context.Context, text string) (int, error)
+}
+
+// estimateTokens 在无法使用分词器时估算token数：
+// 非ASCII字符（例如中文）大约每个字符1个token，ASCII文本大约每4个字节1个token
+func estimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// tokenCounter 统计token数：用真实分词结果校准估算值，避免对每个hunk都调用分词器
+type tokenCounter struct {
+	ctx       context.Context
+	tokenizer Tokenizer // 为nil时只能估算
+	ratio     float64   // 真实token数与估算值之比
+}
+
+// newTokenCounter 创建token计数器，sample用于校准估算值
+func newTokenCounter(ctx context.Context, tokenizer Tokenizer, sample string) *tokenCounter {
+	counter := &tokenCounter{ctx: ctx, tokenizer: tokenizer, ratio: 1}
+	if tokenizer == nil || sample == "" {
+		return counter
+	}
+	if n, err := tokenizer.CountTokens(ctx, sample); err == nil && n > 0 {
+		counter.ratio = float64(n) / float64(estimateTokens(sample))
+	} else {
+		counter.tokenizer = nil
+	}
+	return counter
+}
+
+// count 返回校准后的估算token数
+func (t *tokenCounter) count(text string) int {
+	return int(float64(estimateTokens(text))*t.ratio + 0.5)
+}
+
+// exact 返回真实token数，分词器不可用时返回估算值
+func (t *tokenCounter) exact(text string) int {
+	if t.tokenizer != nil {
+		if n, err := t.tokenizer.CountTokens(t.ctx, text); err == nil {
+			return n
+		}
+	}
+	return t.count(text)
+}
+
+// promptBudget 返回提示词（系统提示+用户提示）可以使用的token数：
+// 上下文长度减去生成的token数，思考模式下还要减去为推理内容预留的token数
+func (c *Client) promptBudget() int {
+	contextSize := c.contextSize()
+	if contextSize <= 0 {
+		contextSize = defaultContextSize
+	}
+	budget := contextSize - c.generationTokens() - templateOverhead
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// newCounter 为当前后端创建token计数器，sample用于校准估算值
+func (c *Client) newCounter(ctx context.Context, sample string) *tokenCounter {
+	var tokenizer Tokenizer
+	if backend, err := c.getBackend(); err == nil {
+		tokenizer, _ = backend.(Tokenizer)
+	}
+	return newTokenCounter(ctx, tokenizer, sample)
+}
+
+// diffBudget 返回差异详情可以使用的token数：提示词预算减去系统提示和提示词固定部分
+func (c *Client) diffBudget(diffInfo *git.DiffInfo, counter *tokenCounter) (int, error) {
+	// 用空的差异详情计算固定部分占用的token数
+	prompt, err := c.renderDiffPrompt(diffInfo, 0, counter.count)
+	if err != nil {
+		return 0, err
+	}
+	fixed := counter.count(c.prompts().system) + counter.count(prompt)
+	return c.promptBudget() - fixed, nil
+}
+
+// buildPrompt 按token预算构建提示信息，后端支持分词时使用真实token数
+func (c *Client) buildPrompt(ctx context.Context, diffInfo *git.DiffInfo) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	counter := c.newCounter(ctx, diffInfo.RawDiff)
+
+	budget := c.promptBudget()
+	diffBudget, err := c.diffBudget(diffInfo, counter)
+	if err != nil {
+		return "", err
+	}
+
+	p := c.prompts()
+	prompt, err := c.renderDiffPrompt(diffInfo, diffBudget, counter.count)
+	if err != nil {
+		return "", err
+	}
+	// 估算可能有偏差，用真实token数校验，超出预算时缩小差异详情的预算重新打包
+	for i := 0; i < 3 && diffBudget > 0; i++ {
+		total := counter.exact(p.system + "\n" + prompt)
+		if total <= budget {
+			break
+		}
+		diffBudget -= total - budget + budget/20
+		if prompt, err = c.renderDiffPrompt(diffInfo, diffBudget, counter.count); err != nil {
+			return "", err
+		}
+	}
+
+	if c.debug {
+		fmt.Println(i18n.T(i18n.AIDebugTokenBudget, budget, diffBudget))
+	}
+	return prompt, nil
+}
+
+// CountTokens 调用llama-tokenize统计token数
+func (b *LlamaCLIBackend) CountTokens(ctx context.Context, text string) (int, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		b.llamaCppPath+"/llama-tokenize",
+		"-m", b.modelPath,
+		"--stdin",
+		"--ids",
+		"--log-disable",
+	)
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+b.llamaCppPath)
+	cmd.Stdin = strings.NewReader(text)
+	setProcessGroup(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, i18n.Wrap(fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String())), i18n.AITokenizeFailed)
+	}
+
+	// --ids 输出形如 [151644, 872, 198]
+	ids := string(output)
+	start, end := strings.Index(ids, "["), strings.LastIndex(ids, "]")
+	if start == -1 || end < start {
+		return 0, i18n.New(i18n.AITokenizeParse)
+	}
+	ids = strings.TrimSpace(ids[start+1 : end])
+	if ids == "" {
+		return 0, nil
+	}
+	return strings.Count(ids, ",") + 1, nil
+}
+
+// tokenizeResponse 是llama-server /tokenize接口的响应体
+type tokenizeResponse struct {
+	Tokens []int `json:"tokens"`
+}
+
+// CountTokens 调用llama-server的/tokenize接口统计token数
+func (b *LlamaServerBackend) CountTokens(ctx context.Context, text string) (int, error) {
+	if err := b.ensureRunning(ctx); err != nil {
+		return 0, err
+	}
+	var resp tokenizeResponse
+	body := map[string]string{"content": text}
+	if err := postJSON(ctx, b.httpClient, b.serverURL+"/tokenize", nil, body, &resp); err != nil {
+		return 0, err
+	}
+	return len(resp.Tokens), nil
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/git"
+)
+
+// fakeTokenizer 用count统计token数
+type fakeTokenizer func(text string) (int, error)
+
+// CountTokens 返回count的结果
+func (f fakeTokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	return f(text)
+}
+
+// tokenizingBackend 是可以统计token数的假后端
+type tokenizingBackend struct {
+	scriptedBackend
+	fakeTokenizer
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for text, want := range map[string]int{"": 0, "abcd": 1, "abcde": 2, "中文": 2, "ab中": 2, "修改 cache": 4} {
+		if got := estimateTokens(text); got != want {
+			t.Errorf("%q: got %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestTokenCounter(t *testing.T) {
+	double := fakeTokenizer(func(text string) (int, error) { return 2 * estimateTokens(text), nil })
+	counter := newTokenCounter(context.Background(), double, strings.Repeat("sample text ", 10))
+	if got := counter.count("abcdefgh"); got != 4 {
+		t.Errorf("calibrated count: got %d, want 4", got)
+	}
+	if got := counter.exact("abcd"); got != 2 {
+		t.Errorf("exact: got %d, want 2", got)
+	}
+
+	// 分词器不可用时只估算
+	failing := fakeTokenizer(func(text string) (int, error) { return 0, errors.New("no tokenizer") })
+	counter = newTokenCounter(context.Background(), failing, "sample")
+	if counter.tokenizer != nil || counter.count("abcdefgh") != 2 || counter.exact("abcdefgh") != 2 {
+		t.Errorf("counter should fall back to estimates: %+v", counter)
+	}
+}
+
+func TestBuildPromptWithinBudget(t *testing.T) {
+	// 每个字符一个token，与估算值相差很大，需要校验后重新打包
+	backend := &tokenizingBackend{fakeTokenizer: func(text string) (int, error) { return runeCount(text), nil }}
+	c := newScriptedClient(backend)
+	c.SetContextSize(2048)
+	c.SetMaxTokens(256)
+
+	var rawDiff strings.Builder
+	files := []string{}
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		rawDiff.WriteString(fileDiff(name, "changed line with some content", 20, 20))
+		files = append(files, name)
+	}
+	diffInfo := &git.DiffInfo{Files: files, RawDiff: rawDiff.String()}
+
+	prompt, err := c.buildPrompt(context.Background(), diffInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, budget := runeCount(c.prompts().system+"\n"+prompt), c.promptBudget(); total > budget {
+		t.Errorf("prompt uses %d tokens, budget %d", total, budget)
+	}
+	if !strings.Contains(prompt, c.prompts().diffSummary) {
+		t.Errorf("diff should be abridged:\n%s", prompt)
+	}
+}
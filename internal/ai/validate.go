@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/lint"
+)
+
+// SetRules 设置生成结果需要满足的规则，规则同时决定提示词和约束解码中可选的类型和范围，为nil时使用默认的类型且不检查
+func (c *Client) SetRules(rules *lint.Rules) {
+	c.rules = rules
+}
+
+// SetValidate 设置是否按规则检查生成结果，关闭时规则只用于提示词和约束解码
+func (c *Client) SetValidate(validate bool) {
+	c.validate = validate
+}
+
+// allowedTypes 返回提示词和约束解码中可选的类型：规则中有类型列表时使用规则，否则使用CommitTypes
+func allowedTypes(rules *lint.Rules) []string {
+	if rules == nil || len(rules.Types) == 0 {
+		return CommitTypes
+	}
+	return rules.Types
+}
+
+// allowedScopes 返回提示词和约束解码中可选的范围，规则没有限定范围时返回nil
+func allowedScopes(rules *lint.Rules) []string {
+	if rules == nil || rules.ScopesNever {
+		return nil
+	}
+	return rules.Scopes
+}
+
+// lintCommitMessage 按规则检查commit message并自动修正能修正的问题，
+// 所有问题记录在Violations中，返回需要模型重新生成的问题
+func (c *Client) lintCommitMessage(commitMsg *CommitMessage) []lint.Violation {
+	if c.rules == nil || !c.validate {
+		return nil
+	}
+
+	msg := lint.Message{
+		Type:     commitMsg.Type,
+		Scope:    commitMsg.Scope,
+		Subject:  commitMsg.Subject,
+		Body:     commitMsg.Body,
+		Breaking: commitMsg.BreakingChanges,
+	}
+	violations := c.rules.Fix(&msg)
+	commitMsg.Type, commitMsg.Scope, commitMsg.Subject, commitMsg.Body = msg.Type, msg.Scope, msg.Subject, msg.Body
+	commitMsg.Violations = violations
+	return lint.Unfixed(violations)
+}
+
+// buildViolationPrompt 把不符合规范的回复和问题列表追加到原始提示之后，用于重新生成
+func buildViolationPrompt(p *promptLang, prompt, badOutput string, violations []lint.Violation) string {
+	var sb strings.Builder
+
+	sb.WriteString(prompt)
+	sb.WriteString(p.violations)
+	for _, v := range violations {
+		sb.WriteString("- " + v.Localize(p.locale) + "\n")
+	}
+	sb.WriteString(p.repairOutput)
+	sb.WriteString("```\n")
+	sb.WriteString(strings.TrimSpace(badOutput))
+	sb.WriteString("\n```\n")
+	sb.WriteString(fmt.Sprintf(p.repairAsk, strings.Join(commitMessageFieldNames(), p.fieldSep)))
+
+	return sb.String()
+}
+
+// joinViolations 用指定的语言把问题列表合并为一行
+func joinViolations(violations []lint.Violation, locale string) string {
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Localize(locale)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// 缓存目录下的子目录
+const (
+	responsesDir = "responses" // 生成结果
+	hashesDir    = "hashes"    // 大文件（模型文件）的哈希
+)
+
+// Dir 返回默认的缓存目录：$XDG_CACHE_HOME/aimmit，未设置时使用系统的用户缓存目录
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.CacheDir)
+	}
+	return filepath.Join(dir, "aimmit"), nil
+}
+
+// Key 把多个组成部分合并为缓存键
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		io.WriteString(hash, part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Cache 是保存在磁盘上的缓存，每个条目是一个JSON文件
+type Cache struct {
+	dir string // 缓存目录
+}
+
+// NewCache 创建使用dir作为缓存目录的缓存，目录在第一次写入时创建
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Get 读取key对应的条目到v中，条目不存在时返回false
+func (c *Cache) Get(key string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(c.entryPath(responsesDir, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, i18n.Wrap(err, i18n.CacheRead)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, i18n.Wrap(err, i18n.CacheRead)
+	}
+	return true, nil
+}
+
+// Put 把v保存为key对应的条目
+func (c *Cache) Put(key string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	return c.write(c.entryPath(responsesDir, key), data)
+}
+
+// Clear 删除缓存目录中的所有内容，返回删除的生成结果数
+func (c *Cache) Clear() (int, error) {
+	entries, err := os.ReadDir(filepath.Join(c.dir, responsesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return 0, i18n.Wrap(err, i18n.CacheClear)
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		return 0, i18n.Wrap(err, i18n.CacheClear)
+	}
+	return len(entries), nil
+}
+
+// fileHash 是缓存的文件哈希，文件大小和修改时间不变时认为内容没有变化
+type fileHash struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256"`
+}
+
+// FileHash 返回文件内容的SHA-256。模型文件很大，计算结果按路径缓存，
+// 文件大小和修改时间不变时直接使用缓存的结果
+func (c *Cache) FileHash(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.CacheHashFile, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.CacheHashFile, path)
+	}
+
+	entryPath := c.entryPath(hashesDir, Key(path))
+	var cached fileHash
+	if data, err := os.ReadFile(entryPath); err == nil && json.Unmarshal(data, &cached) == nil {
+		if cached.Path == path && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+			return cached.SHA256, nil
+		}
+	}
+
+	sum, err := HashFile(path)
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.CacheHashFile, path)
+	}
+	cached = fileHash{Path: path, Size: info.Size(), ModTime: info.ModTime(), SHA256: sum}
+	if data, err := json.Marshal(cached); err == nil {
+		// 写入失败只会导致下次重新计算
+		c.write(entryPath, data)
+	}
+	return sum, nil
+}
+
+// HashFile 计算文件内容的SHA-256，不使用缓存
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// entryPath 返回条目的文件路径
+func (c *Cache) entryPath(kind, key string) string {
+	return filepath.Join(c.dir, kind, key+".json")
+}
+
+// write 先写入临时文件再重命名，避免并发运行时读到写了一半的条目
+func (c *Cache) write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+strings.TrimSuffix(filepath.Base(path), ".json")+"-*")
+	if err != nil {
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	if err := tmp.Close(); err != nil {
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return i18n.Wrap(err, i18n.CacheWrite)
+	}
+	return nil
+}
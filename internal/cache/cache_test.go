@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// entry 是测试使用的缓存条目
+type entry struct {
+	Subject string   `json:"subject"`
+	Files   []string `json:"files"`
+}
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("parts should be separated")
+	}
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("key is not stable")
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	c := NewCache(filepath.Join(t.TempDir(), "aimmit"))
+	var got entry
+	if found, err := c.Get(Key("missing"), &got); found || err != nil {
+		t.Fatalf("missing entry: got found %v, err %v", found, err)
+	}
+
+	want := entry{Subject: "add a cache", Files: []string{"a.go", "b.go"}}
+	if err := c.Put(Key("a"), want); err != nil {
+		t.Fatal(err)
+	}
+	found, err := c.Get(Key("a"), &got)
+	if !found || err != nil {
+		t.Fatalf("got found %v, err %v", found, err)
+	}
+	if got.Subject != want.Subject || len(got.Files) != 2 || got.Files[1] != "b.go" {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// 损坏的条目
+	if err := os.WriteFile(c.entryPath(responsesDir, Key("a")), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(Key("a"), &got); i18n.CodeOf(err) != i18n.CacheRead {
+		t.Errorf("want %s, got %v", i18n.CacheRead, err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := NewCache(filepath.Join(t.TempDir(), "aimmit"))
+	if n, err := c.Clear(); n != 0 || err != nil {
+		t.Fatalf("empty cache: got %d, %v", n, err)
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(Key(key), entry{Subject: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := c.Clear()
+	if n != 2 || err != nil {
+		t.Fatalf("got %d, %v, want 2 entries", n, err)
+	}
+	var got entry
+	if found, err := c.Get(Key("a"), &got); found || err != nil {
+		t.Errorf("after Clear: got found %v, err %v", found, err)
+	}
+}
+
+func TestFileHash(t *testing.T) {
+	c := NewCache(filepath.Join(t.TempDir(), "aimmit"))
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	writeFile := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	writeFile("model v1", modTime)
+	first, err := c.FileHash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum, err := HashFile(path); err != nil || sum != first {
+		t.Fatalf("HashFile: got %s, %v, want %s", sum, err, first)
+	}
+
+	// 大小和修改时间不变时使用缓存的结果，即使内容已经变化
+	writeFile("model v2", modTime)
+	if sum, _ := c.FileHash(path); sum != first {
+		t.Errorf("unchanged size and mtime: got %s, want the cached %s", sum, first)
+	}
+
+	// 修改时间变化时重新计算
+	writeFile("model v2", modTime.Add(time.Minute))
+	second, err := c.FileHash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := HashFile(path); second != want || second == first {
+		t.Errorf("changed mtime: got %s, want %s", second, want)
+	}
+
+	// 大小变化时重新计算
+	writeFile("model v3!", modTime.Add(time.Minute))
+	third, err := c.FileHash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := HashFile(path); third != want || third == second {
+		t.Errorf("changed size: got %s, want %s", third, want)
+	}
+
+	if _, err := c.FileHash(filepath.Join(t.TempDir(), "missing.gguf")); i18n.CodeOf(err) != i18n.CacheHashFile {
+		t.Errorf("missing file: want %s, got %v", i18n.CacheHashFile, err)
+	}
+}
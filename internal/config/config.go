@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// Config 是保存在用户配置目录中的设置
+type Config struct {
+	Model     string   `json:"model,omitempty"`      // 默认使用的GGUF模型文件路径
+	ModelDirs []string `json:"model_dirs,omitempty"` // 除项目model目录外，查找GGUF模型的目录
+	Lang      string   `json:"lang,omitempty"`       // 默认的commit message语言
+}
+
+// Path 返回配置文件的路径：用户配置目录中的aimmit/config.json
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.ConfigDir)
+	}
+	return filepath.Join(dir, "aimmit", "config.json"), nil
+}
+
+// Load 读取配置文件，文件不存在时返回空的配置
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, i18n.Wrap(err, i18n.ConfigRead, path)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, i18n.Wrap(err, i18n.ConfigRead, path)
+	}
+	return &cfg, nil
+}
+
+// Save 把配置写入配置文件，返回配置文件的路径
+func (c *Config) Save() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.ConfigWrite, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", i18n.Wrap(err, i18n.ConfigWrite, path)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", i18n.Wrap(err, i18n.ConfigWrite, path)
+	}
+	return path, nil
+}
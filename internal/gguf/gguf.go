@@ -0,0 +1,307 @@
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// magic 是GGUF文件开头的魔数（小端序的"GGUF"）
+const magic = 0x46554747
+
+// 元数据值的类型
+const (
+	typeUint8   = 0
+	typeInt8    = 1
+	typeUint16  = 2
+	typeInt16   = 3
+	typeUint32  = 4
+	typeInt32   = 5
+	typeFloat32 = 6
+	typeBool    = 7
+	typeString  = 8
+	typeArray   = 9
+	typeUint64  = 10
+	typeInt64   = 11
+	typeFloat64 = 12
+)
+
+// maxStringLength 限制单个字符串的长度，防止损坏的文件导致分配过多内存
+const maxStringLength = 16 << 20
+
+// ErrNotGGUF 表示文件不是GGUF格式
+var ErrNotGGUF = i18n.New(i18n.GGUFNotGGUF)
+
+// errTruncated 返回文件被截断的错误，可以用errors.Is判断是否为io.ErrUnexpectedEOF
+func errTruncated() error {
+	return i18n.Wrap(io.ErrUnexpectedEOF, i18n.GGUFTruncated)
+}
+
+// Metadata 表示GGUF文件头中的元数据
+type Metadata struct {
+	Version     uint32                 // GGUF版本
+	TensorCount uint64                 // 张量数量
+	KV          map[string]interface{} // 元数据键值对（数组类型的值只记录元素个数）
+}
+
+// ReadFile 读取GGUF文件的元数据，只读取文件头，不会加载张量数据
+func ReadFile(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.GGUFOpen)
+	}
+	defer f.Close()
+
+	metadata, err := Read(f)
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.GGUFRead, path)
+	}
+	return metadata, nil
+}
+
+// Read 从r中读取GGUF元数据
+func Read(r io.Reader) (*Metadata, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	if d.uint32() != magic {
+		if d.err != nil {
+			return nil, d.err
+		}
+		return nil, ErrNotGGUF
+	}
+
+	metadata := &Metadata{KV: map[string]interface{}{}}
+	metadata.Version = d.uint32()
+	if d.err == nil && (metadata.Version < 1 || metadata.Version > 3) {
+		return nil, i18n.New(i18n.GGUFVersion, metadata.Version)
+	}
+	// v1中计数和字符串长度是uint32，v2起改为uint64
+	d.v1 = metadata.Version == 1
+
+	metadata.TensorCount = d.count()
+	kvCount := d.count()
+	for i := uint64(0); i < kvCount && d.err == nil; i++ {
+		key := d.string()
+		valueType := d.uint32()
+		metadata.KV[key] = d.value(valueType)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return metadata, nil
+}
+
+// String 返回字符串类型的元数据，不存在时返回空字符串
+func (m *Metadata) String(key string) string {
+	s, _ := m.KV[key].(string)
+	return s
+}
+
+// Uint 返回整数类型的元数据，不存在时返回0
+func (m *Metadata) Uint(key string) uint64 {
+	switch v := m.KV[key].(type) {
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case uint64:
+		return v
+	case int8:
+		return uint64(v)
+	case int16:
+		return uint64(v)
+	case int32:
+		return uint64(v)
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
+
+// Architecture 返回模型架构，例如qwen3、llama
+func (m *Metadata) Architecture() string {
+	return m.String("general.architecture")
+}
+
+// Name 返回模型名称
+func (m *Metadata) Name() string {
+	return m.String("general.name")
+}
+
+// ContextLength 返回模型训练时的上下文长度
+func (m *Metadata) ContextLength() int {
+	return int(m.Uint(m.Architecture() + ".context_length"))
+}
+
+// SizeLabel 返回模型的参数规模，例如1.7B
+func (m *Metadata) SizeLabel() string {
+	return m.String("general.size_label")
+}
+
+// fileTypes 是general.file_type对应的量化类型名称（与llama.cpp的llama_ftype一致）
+var fileTypes = map[uint64]string{
+	0: "F32", 1: "F16", 2: "Q4_0", 3: "Q4_1", 7: "Q8_0", 8: "Q5_0", 9: "Q5_1",
+	10: "Q2_K", 11: "Q3_K_S", 12: "Q3_K_M", 13: "Q3_K_L", 14: "Q4_K_S", 15: "Q4_K_M",
+	16: "Q5_K_S", 17: "Q5_K_M", 18: "Q6_K", 19: "IQ2_XXS", 20: "IQ2_XS", 21: "Q2_K_S",
+	22: "IQ3_XS", 23: "IQ3_XXS", 24: "IQ1_S", 25: "IQ4_NL", 26: "IQ3_S", 27: "IQ3_M",
+	28: "IQ2_S", 29: "IQ2_M", 30: "IQ4_XS", 31: "IQ1_M", 32: "BF16",
+}
+
+// FileType 返回模型的量化类型，例如Q6_K，未知时返回空字符串
+func (m *Metadata) FileType() string {
+	if _, ok := m.KV["general.file_type"]; !ok {
+		return ""
+	}
+	return fileTypes[m.Uint("general.file_type")]
+}
+
+// ChatTemplate 返回模型内置的Jinja对话模板
+func (m *Metadata) ChatTemplate() string {
+	return m.String("tokenizer.chat_template")
+}
+
+// decoder 按小端序读取GGUF数据，遇到错误后后续读取都返回零值
+type decoder struct {
+	r   *bufio.Reader
+	v1  bool
+	err error
+}
+
+// read 读取定长数据
+func (d *decoder) read(data interface{}) {
+	if d.err != nil {
+		return
+	}
+	if err := binary.Read(d.r, binary.LittleEndian, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errTruncated()
+		}
+		d.err = err
+	}
+}
+
+func (d *decoder) uint32() uint32 {
+	var v uint32
+	d.read(&v)
+	return v
+}
+
+// count 读取计数或长度，v1为uint32，v2起为uint64
+func (d *decoder) count() uint64 {
+	if d.v1 {
+		return uint64(d.uint32())
+	}
+	var v uint64
+	d.read(&v)
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	if n > maxStringLength {
+		d.err = i18n.New(i18n.GGUFStringTooLong, n)
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		d.err = errTruncated()
+		return ""
+	}
+	return string(buf)
+}
+
+// value 读取一个指定类型的值，数组只返回元素个数，元素本身会被跳过
+func (d *decoder) value(valueType uint32) interface{} {
+	switch valueType {
+	case typeUint8:
+		var v uint8
+		d.read(&v)
+		return v
+	case typeInt8:
+		var v int8
+		d.read(&v)
+		return v
+	case typeUint16:
+		var v uint16
+		d.read(&v)
+		return v
+	case typeInt16:
+		var v int16
+		d.read(&v)
+		return v
+	case typeUint32:
+		return d.uint32()
+	case typeInt32:
+		var v int32
+		d.read(&v)
+		return v
+	case typeFloat32:
+		return math.Float32frombits(d.uint32())
+	case typeBool:
+		var v uint8
+		d.read(&v)
+		return v != 0
+	case typeString:
+		return d.string()
+	case typeArray:
+		elemType := d.uint32()
+		n := d.count()
+		d.skipArray(elemType, n)
+		return n
+	case typeUint64:
+		var v uint64
+		d.read(&v)
+		return v
+	case typeInt64:
+		var v int64
+		d.read(&v)
+		return v
+	case typeFloat64:
+		var v float64
+		d.read(&v)
+		return v
+	default:
+		if d.err == nil {
+			d.err = i18n.New(i18n.GGUFUnknownType, valueType)
+		}
+		return nil
+	}
+}
+
+// skipArray 跳过数组元素，例如词表中的大量字符串
+func (d *decoder) skipArray(elemType uint32, n uint64) {
+	size := map[uint32]uint64{
+		typeUint8: 1, typeInt8: 1, typeBool: 1,
+		typeUint16: 2, typeInt16: 2,
+		typeUint32: 4, typeInt32: 4, typeFloat32: 4,
+		typeUint64: 8, typeInt64: 8, typeFloat64: 8,
+	}[elemType]
+	if size > 0 {
+		if _, err := d.r.Discard(int(n * size)); err != nil && d.err == nil {
+			d.err = errTruncated()
+		}
+		return
+	}
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		if elemType == typeString {
+			length := d.count()
+			if d.err == nil {
+				if _, err := d.r.Discard(int(length)); err != nil {
+					d.err = errTruncated()
+				}
+			}
+			continue
+		}
+		d.value(elemType)
+	}
+}
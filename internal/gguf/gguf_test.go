@@ -0,0 +1,159 @@
+package gguf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// header 按GGUF格式拼接测试用的文件头
+type header struct {
+	bytes.Buffer
+	v1 bool
+}
+
+// newHeader 返回写好魔数、版本、张量数和键值对数的文件头
+func newHeader(version uint32, tensors, kvs uint64) *header {
+	h := &header{v1: version == 1}
+	h.u32(magic)
+	h.u32(version)
+	h.count(tensors)
+	h.count(kvs)
+	return h
+}
+
+// u32 写入uint32
+func (h *header) u32(v uint32) {
+	binary.Write(h, binary.LittleEndian, v)
+}
+
+// count 写入计数或长度，v1为uint32，v2起为uint64
+func (h *header) count(v uint64) {
+	if h.v1 {
+		h.u32(uint32(v))
+		return
+	}
+	binary.Write(h, binary.LittleEndian, v)
+}
+
+// str 写入带长度的字符串
+func (h *header) str(s string) {
+	h.count(uint64(len(s)))
+	h.WriteString(s)
+}
+
+// kvString 写入字符串类型的键值对
+func (h *header) kvString(key, value string) {
+	h.str(key)
+	h.u32(typeString)
+	h.str(value)
+}
+
+// kvUint32 写入uint32类型的键值对
+func (h *header) kvUint32(key string, value uint32) {
+	h.str(key)
+	h.u32(typeUint32)
+	h.u32(value)
+}
+
+// kvStrings 写入字符串数组类型的键值对
+func (h *header) kvStrings(key string, values ...string) {
+	h.str(key)
+	h.u32(typeArray)
+	h.u32(typeString)
+	h.count(uint64(len(values)))
+	for _, v := range values {
+		h.str(v)
+	}
+}
+
+// validHeader 返回一个包含常用元数据的文件头
+func validHeader(version uint32) []byte {
+	h := newHeader(version, 2, 5)
+	h.kvString("general.architecture", "qwen3")
+	h.kvString("general.name", "Qwen3 1.7B")
+	h.kvUint32("qwen3.context_length", 40960)
+	h.kvStrings("tokenizer.ggml.tokens", "<|im_start|>", "<|im_end|>", "hello")
+	h.kvUint32("general.file_type", 18)
+	return h.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	for _, version := range []uint32{1, 2, 3} {
+		metadata, err := Read(bytes.NewReader(validHeader(version)))
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if metadata.Version != version || metadata.TensorCount != 2 {
+			t.Errorf("v%d: got version %d, tensors %d", version, metadata.Version, metadata.TensorCount)
+		}
+		if metadata.Architecture() != "qwen3" || metadata.Name() != "Qwen3 1.7B" {
+			t.Errorf("v%d: got architecture %q, name %q", version, metadata.Architecture(), metadata.Name())
+		}
+		if metadata.ContextLength() != 40960 {
+			t.Errorf("v%d: got context length %d", version, metadata.ContextLength())
+		}
+		if n := metadata.Uint("tokenizer.ggml.tokens"); n != 3 {
+			t.Errorf("v%d: got %d tokens, want the array length 3", version, n)
+		}
+		if metadata.FileType() != "Q6_K" {
+			t.Errorf("v%d: got file type %q", version, metadata.FileType())
+		}
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	data := validHeader(3)
+	// 在魔数、版本、计数、键、值和数组元素中间截断
+	for _, n := range []int{2, 6, 12, 30, len(data) - 40, len(data) - 1} {
+		_, err := Read(bytes.NewReader(data[:n]))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("truncated at %d: want io.ErrUnexpectedEOF, got %v", n, err)
+		}
+		if i18n.CodeOf(err) != i18n.GGUFTruncated {
+			t.Errorf("truncated at %d: want %s, got %v", n, i18n.GGUFTruncated, err)
+		}
+	}
+}
+
+func TestReadBadMagic(t *testing.T) {
+	data := validHeader(3)
+	copy(data, "GGML")
+	if _, err := Read(bytes.NewReader(data)); err != ErrNotGGUF {
+		t.Errorf("want ErrNotGGUF, got %v", err)
+	}
+}
+
+func TestReadUnsupportedVersion(t *testing.T) {
+	for _, version := range []uint32{0, 4} {
+		_, err := Read(bytes.NewReader(newHeader(version, 0, 0).Bytes()))
+		if i18n.CodeOf(err) != i18n.GGUFVersion {
+			t.Errorf("v%d: want %s, got %v", version, i18n.GGUFVersion, err)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	if err := os.WriteFile(path, validHeader(3), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadFile(filepath.Join(t.TempDir(), "missing.gguf"))
+	if i18n.CodeOf(err) != i18n.GGUFOpen || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want %s wrapping os.ErrNotExist, got %v", i18n.GGUFOpen, err)
+	}
+	if en := i18n.Localize(err, i18n.En); !strings.HasPrefix(en, "failed to open the model file: ") {
+		t.Errorf("error is not localized: %s", en)
+	}
+}
@@ -1,10 +1,14 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rust17/AImmit/internal/i18n"
 )
 
 // Commit 表示一个Git提交
@@ -13,6 +17,7 @@ type Commit struct {
 	Author  string
 	Date    time.Time
 	Message string
+	Files   []string // 提交修改的文件
 }
 
 // DiffInfo 表示Git差异信息
@@ -22,6 +27,7 @@ type DiffInfo struct {
 	Deletions  int      // 删除的行数
 	RawDiff    string   // 原始diff内容
 	StagedOnly bool     // 是否只包含已暂存的更改
+	Untracked  bool     // 没有差异，RawDiff中只有未跟踪的文件列表
 }
 
 // Client 是Git操作的客户端
@@ -36,45 +42,94 @@ func NewClient(repoPath string) *Client {
 	}
 }
 
+// TopLevel 返回仓库的根目录
+func (c *Client) TopLevel(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", c.RepoPath, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.GitTopLevelFailed)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RecentCommits 获取最近n个非合并提交，按时间从新到旧排列，仓库还没有提交时返回空列表
+func (c *Client) RecentCommits(ctx context.Context, n int) ([]Commit, error) {
+	// 用\x1e分隔提交，用\x1f分隔字段，--name-only输出的文件列表在最后一个字段中
+	cmd := exec.CommandContext(ctx, "git", "-C", c.RepoPath, "log", "--no-merges", "-n", strconv.Itoa(n),
+		"--name-only", "--format=%x1e%H%x1f%an%x1f%aI%x1f%B%x1f")
+	output, err := cmd.Output()
+	if err != nil {
+		// 没有提交时rev-parse HEAD会失败
+		if exec.CommandContext(ctx, "git", "-C", c.RepoPath, "rev-parse", "--verify", "-q", "HEAD").Run() != nil {
+			return []Commit{}, nil
+		}
+		return nil, i18n.Wrap(err, i18n.GitLogFailed)
+	}
+
+	commits := []Commit{}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		files := []string{}
+		for _, file := range strings.Split(fields[4], "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				files = append(files, file)
+			}
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Message: strings.TrimSpace(fields[3]),
+			Files:   files,
+		})
+	}
+	return commits, nil
+}
+
 // GetCurrentDiff 获取当前工作区的差异
-func (c *Client) GetCurrentDiff(stagedOnly bool) (*DiffInfo, error) {
+func (c *Client) GetCurrentDiff(ctx context.Context, stagedOnly bool) (*DiffInfo, error) {
 	var cmd *exec.Cmd
 
 	if stagedOnly {
 		// 只获取已暂存的更改
-		cmd = exec.Command("git", "-C", c.RepoPath, "diff", "--staged")
+		cmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff", "--staged")
 	} else {
 		// 获取所有更改（包括未暂存的）
-		cmd = exec.Command("git", "-C", c.RepoPath, "diff")
+		cmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff")
 	}
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("获取diff失败: %w", err)
+		return nil, i18n.Wrap(err, i18n.GitDiffFailed)
 	}
 
 	rawDiff := string(output)
 
 	// 如果没有差异，尝试获取未跟踪的文件
+	untracked := false
 	if rawDiff == "" && !stagedOnly {
-		cmd = exec.Command("git", "-C", c.RepoPath, "ls-files", "--others", "--exclude-standard")
+		cmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "ls-files", "--others", "--exclude-standard")
 		output, err = cmd.Output()
 		if err == nil && len(output) > 0 {
-			rawDiff = "未跟踪的文件:\n" + string(output)
+			rawDiff = string(output)
+			untracked = true
 		}
 	}
 
 	// 获取修改的文件列表
 	var filesCmd *exec.Cmd
 	if stagedOnly {
-		filesCmd = exec.Command("git", "-C", c.RepoPath, "diff", "--staged", "--name-only")
+		filesCmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff", "--staged", "--name-only")
 	} else {
-		filesCmd = exec.Command("git", "-C", c.RepoPath, "diff", "--name-only")
+		filesCmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff", "--name-only")
 	}
 
 	filesOutput, err := filesCmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("获取修改文件列表失败: %w", err)
+		return nil, i18n.Wrap(err, i18n.GitFilesFailed)
 	}
 
 	files := []string{}
@@ -84,7 +139,7 @@ func (c *Client) GetCurrentDiff(stagedOnly bool) (*DiffInfo, error) {
 
 	// 获取未跟踪的文件
 	if !stagedOnly {
-		untrackedCmd := exec.Command("git", "-C", c.RepoPath, "ls-files", "--others", "--exclude-standard")
+		untrackedCmd := exec.CommandContext(ctx, "git", "-C", c.RepoPath, "ls-files", "--others", "--exclude-standard")
 		untrackedOutput, err := untrackedCmd.Output()
 		if err == nil && len(untrackedOutput) > 0 {
 			untrackedFiles := strings.Split(strings.TrimSpace(string(untrackedOutput)), "\n")
@@ -98,9 +153,9 @@ func (c *Client) GetCurrentDiff(stagedOnly bool) (*DiffInfo, error) {
 	// 使用git diff --stat来获取统计信息
 	var statCmd *exec.Cmd
 	if stagedOnly {
-		statCmd = exec.Command("git", "-C", c.RepoPath, "diff", "--staged", "--stat")
+		statCmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff", "--staged", "--stat")
 	} else {
-		statCmd = exec.Command("git", "-C", c.RepoPath, "diff", "--stat")
+		statCmd = exec.CommandContext(ctx, "git", "-C", c.RepoPath, "diff", "--stat")
 	}
 
 	statOutput, err := statCmd.Output()
@@ -130,6 +185,7 @@ func (c *Client) GetCurrentDiff(stagedOnly bool) (*DiffInfo, error) {
 		Additions:  additions,
 		Deletions:  deletions,
 		RawDiff:    rawDiff,
+		Untracked:  untracked,
 		StagedOnly: stagedOnly,
 	}, nil
 }
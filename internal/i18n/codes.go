@@ -0,0 +1,232 @@
+package i18n
+
+// 命令行界面的消息
+const (
+	CLIGetDiffFailed       Code = "cli.get_diff_failed"
+	CLINoChanges           Code = "cli.no_changes"
+	CLIGenerating          Code = "cli.generating"
+	CLIGenerateFailed      Code = "cli.generate_failed"
+	CLIFormatFailed        Code = "cli.format_failed"
+	CLIFormatCommitFailed  Code = "cli.format_commit_failed"
+	CLICommitFailed        Code = "cli.commit_failed"
+	CLICommitted           Code = "cli.committed"
+	CLICanceled            Code = "cli.canceled"
+	CLISelectCandidate     Code = "cli.select_candidate"
+	CLIReadInputFailed     Code = "cli.read_input_failed"
+	CLIInvalidChoice       Code = "cli.invalid_choice"
+	CLIElapsed             Code = "cli.elapsed"
+	CLIHistoryFailed       Code = "cli.history_failed"
+	CLICacheUsage          Code = "cli.cache_usage"
+	CLICacheCleared        Code = "cli.cache_cleared"
+	CLICacheFailed         Code = "cli.cache_failed"
+	CLIConfigFailed        Code = "cli.config_failed"
+	CLIModelUsage          Code = "cli.model_usage"
+	CLIModelFailed         Code = "cli.model_failed"
+	CLIModelNone           Code = "cli.model_none"
+	CLIModelHeader         Code = "cli.model_header"
+	CLIModelInvalid        Code = "cli.model_invalid"
+	CLIModelNotFound       Code = "cli.model_not_found"
+	CLIModelUsed           Code = "cli.model_used"
+	CLIModelVerifyOK       Code = "cli.model_verify_ok"
+	CLIModelVerifyMismatch Code = "cli.model_verify_mismatch"
+	CLIModelVerifyUnlisted Code = "cli.model_verify_unlisted"
+	CLIModelVerifyMissing  Code = "cli.model_verify_missing"
+	CLIModelVerifySummary  Code = "cli.model_verify_summary"
+	CLIHeuristicFallback   Code = "cli.heuristic_fallback"
+	CLILintViolations      Code = "cli.lint_violations"
+	CLICommitlintFailed    Code = "cli.commitlint_failed"
+	CLICommitlintLoaded    Code = "cli.commitlint_loaded"
+	CLIUnsupportedLocale   Code = "cli.unsupported_locale"
+)
+
+// 命令行参数的说明
+const (
+	FlagFormat           Code = "flag.format"
+	FlagRepo             Code = "flag.repo"
+	FlagStaged           Code = "flag.staged"
+	FlagAutoCommit       Code = "flag.auto_commit"
+	FlagDebug            Code = "flag.debug"
+	FlagOnlyPrompt       Code = "flag.only_prompt"
+	FlagLlamaCPath       Code = "flag.llama_c_path"
+	FlagModelPath        Code = "flag.model_path"
+	FlagBackend          Code = "flag.backend"
+	FlagServerURL        Code = "flag.server_url"
+	FlagServerEndpoint   Code = "flag.server_endpoint"
+	FlagSpawnServer      Code = "flag.spawn_server"
+	FlagModelName        Code = "flag.model_name"
+	FlagChatTemplate     Code = "flag.chat_template"
+	FlagOpenAIBaseURL    Code = "flag.openai_base_url"
+	FlagOpenAIAPIKeyEnv  Code = "flag.openai_api_key_env"
+	FlagOpenAITimeout    Code = "flag.openai_timeout"
+	FlagOpenAIKwargs     Code = "flag.openai_kwargs"
+	FlagJSONMode         Code = "flag.json_mode"
+	FlagOllamaURL        Code = "flag.ollama_url"
+	FlagOllamaModel      Code = "flag.ollama_model"
+	FlagOllamaEndpoint   Code = "flag.ollama_endpoint"
+	FlagReplayDir        Code = "flag.replay_dir"
+	FlagRecord           Code = "flag.record"
+	FlagConstrain        Code = "flag.constrain"
+	FlagRetries          Code = "flag.retries"
+	FlagCandidates       Code = "flag.candidates"
+	FlagMapReduce        Code = "flag.map_reduce"
+	FlagStream           Code = "flag.stream"
+	FlagTemperature      Code = "flag.temperature"
+	FlagMaxTokens        Code = "flag.max_tokens"
+	FlagTopP             Code = "flag.top_p"
+	FlagTopK             Code = "flag.top_k"
+	FlagMinP             Code = "flag.min_p"
+	FlagSeed             Code = "flag.seed"
+	FlagTimeout          Code = "flag.timeout"
+	FlagThreads          Code = "flag.threads"
+	FlagParallel         Code = "flag.parallel"
+	FlagCtxSize          Code = "flag.ctx_size"
+	FlagLang             Code = "flag.lang"
+	FlagHistory          Code = "flag.history"
+	FlagNoCache          Code = "flag.no_cache"
+	FlagThink            Code = "flag.think"
+	FlagThinkTokens      Code = "flag.think_tokens"
+	FlagShowThinking     Code = "flag.show_thinking"
+	FlagOfflineHeuristic Code = "flag.offline_heuristic"
+	FlagFallback         Code = "flag.fallback"
+	FlagValidate         Code = "flag.validate"
+	FlagModelDir         Code = "flag.model_dir"
+	FlagLocale           Code = "flag.locale"
+)
+
+// git包的消息
+const (
+	GitDiffFailed     Code = "git.diff_failed"
+	GitFilesFailed    Code = "git.files_failed"
+	GitTopLevelFailed Code = "git.top_level_failed"
+	GitLogFailed      Code = "git.log_failed"
+)
+
+// ai包的错误
+const (
+	AIUnsupportedConstrain      Code = "ai.unsupported_constrain"
+	AIPartialUnparsable         Code = "ai.partial_unparsable"
+	AIUnsupportedBackend        Code = "ai.unsupported_backend"
+	AIUnsupportedLang           Code = "ai.unsupported_lang"
+	AIUnsupportedTemplate       Code = "ai.unsupported_template"
+	AIUnsupportedServerEndpoint Code = "ai.unsupported_server_endpoint"
+	AIUnsupportedOllamaEndpoint Code = "ai.unsupported_ollama_endpoint"
+	AIMarshalRequest            Code = "ai.marshal_request"
+	AICreateRequest             Code = "ai.create_request"
+	AIRequestTimeout            Code = "ai.request_timeout"
+	AIRequestFailed             Code = "ai.request_failed"
+	AIHTTPStatus                Code = "ai.http_status"
+	AIReadResponse              Code = "ai.read_response"
+	AIParseResponse             Code = "ai.parse_response"
+	AIReadStream                Code = "ai.read_stream"
+	AIParseStream               Code = "ai.parse_stream"
+	AIMarshalSchema             Code = "ai.marshal_schema"
+	AINoChoices                 Code = "ai.no_choices"
+	AIServerNotRunning          Code = "ai.server_not_running"
+	AIParseServerURL            Code = "ai.parse_server_url"
+	AIStartServer               Code = "ai.start_server"
+	AIServerReadyTimeout        Code = "ai.server_ready_timeout"
+	AISummarizeFile             Code = "ai.summarize_file"
+	AIOllamaFailed              Code = "ai.ollama_failed"
+	AIOpenAIFailed              Code = "ai.openai_failed"
+	AIReplayNotFound            Code = "ai.replay_not_found"
+	AIReplayRead                Code = "ai.replay_read"
+	AIReplayParse               Code = "ai.replay_parse"
+	AIReplayMarshal             Code = "ai.replay_marshal"
+	AIReplayMkdir               Code = "ai.replay_mkdir"
+	AIReplaySave                Code = "ai.replay_save"
+	AICreatePipe                Code = "ai.create_pipe"
+	AIProcessStart              Code = "ai.process_start"
+	AIProcessTimeout            Code = "ai.process_timeout"
+	AIProcessExit               Code = "ai.process_exit"
+	AIProcessRead               Code = "ai.process_read"
+	AITokenizeFailed            Code = "ai.tokenize_failed"
+	AITokenizeParse             Code = "ai.tokenize_parse"
+	AINoJSON                    Code = "ai.no_json"
+	AIInvalidJSON               Code = "ai.invalid_json"
+	AISchemaViolation           Code = "ai.schema_violation"
+	AIFieldType                 Code = "ai.field_type"
+	AIMissingField              Code = "ai.missing_field"
+	AIPromptTemplateLoad        Code = "ai.prompt_template_load"
+	AIPromptTemplateExec        Code = "ai.prompt_template_exec"
+	AIUnknownFile               Code = "ai.unknown_file"
+)
+
+// config包的错误
+const (
+	ConfigDir   Code = "config.dir"
+	ConfigRead  Code = "config.read"
+	ConfigWrite Code = "config.write"
+)
+
+// models包的错误
+const (
+	ModelsScan     Code = "models.scan"
+	ModelsManifest Code = "models.manifest"
+	ModelsHash     Code = "models.hash"
+)
+
+// gguf包的错误
+const (
+	GGUFNotGGUF       Code = "gguf.not_gguf"
+	GGUFOpen          Code = "gguf.open"
+	GGUFRead          Code = "gguf.read"
+	GGUFVersion       Code = "gguf.version"
+	GGUFTruncated     Code = "gguf.truncated"
+	GGUFStringTooLong Code = "gguf.string_too_long"
+	GGUFUnknownType   Code = "gguf.unknown_type"
+)
+
+// cache包的错误
+const (
+	CacheDir      Code = "cache.dir"
+	CacheRead     Code = "cache.read"
+	CacheWrite    Code = "cache.write"
+	CacheClear    Code = "cache.clear"
+	CacheHashFile Code = "cache.hash_file"
+)
+
+// ai包的debug输出
+const (
+	AIDebugGGUFFailed      Code = "ai.debug.gguf_failed"
+	AIDebugUsage           Code = "ai.debug.usage"
+	AIDebugModelInfo       Code = "ai.debug.model_info"
+	AIDebugUnknown         Code = "ai.debug.unknown"
+	AIDebugSalvaged        Code = "ai.debug.salvaged"
+	AIDebugRetry           Code = "ai.debug.retry"
+	AIDebugCandidateFailed Code = "ai.debug.candidate_failed"
+	AIDebugStartServer     Code = "ai.debug.start_server"
+	AIDebugFileSummary     Code = "ai.debug.file_summary"
+	AIDebugTokenBudget     Code = "ai.debug.token_budget"
+	AIDebugPromptTemplate  Code = "ai.debug.prompt_template"
+	AIDebugCacheHit        Code = "ai.debug.cache_hit"
+	AIDebugCacheSkipped    Code = "ai.debug.cache_skipped"
+	AIDebugCacheFailed     Code = "ai.debug.cache_failed"
+	AIDebugThinking        Code = "ai.debug.thinking"
+	AIDebugLintRetry       Code = "ai.debug.lint_retry"
+)
+
+// lint包的违规描述
+const (
+	LintTypeEmpty          Code = "lint.type_empty"
+	LintTypeEnum           Code = "lint.type_enum"
+	LintScopeCharset       Code = "lint.scope_charset"
+	LintScopeEnum          Code = "lint.scope_enum"
+	LintSubjectEmpty       Code = "lint.subject_empty"
+	LintSubjectFullStop    Code = "lint.subject_full_stop"
+	LintSubjectCaseNever   Code = "lint.subject_case_never"
+	LintSubjectCaseAlways  Code = "lint.subject_case_always"
+	LintSubjectMaxLength   Code = "lint.subject_max_length"
+	LintHeaderMaxLength    Code = "lint.header_max_length"
+	LintBodyMaxLineLength  Code = "lint.body_max_line_length"
+	LintConfigRead         Code = "lint.config_read"
+	LintConfigParse        Code = "lint.config_parse"
+	LintConfigRule         Code = "lint.config_rule"
+	LintConfigUnterminated Code = "lint.config_unterminated"
+	LintYAMLSyntax         Code = "lint.yaml_syntax"
+)
+
+// summarizer包的错误
+const (
+	SummarizerUnsupportedFormat Code = "summarizer.unsupported_format"
+	SummarizerMarshalJSON       Code = "summarizer.marshal_json"
+)
@@ -0,0 +1,202 @@
+package i18n
+
+// enMessages 是英文消息目录
+var enMessages = map[Code]string{
+	CLIGetDiffFailed:       "Failed to get diff: %v",
+	CLINoChanges:           "No changes detected",
+	CLIGenerating:          "Generating commit message...",
+	CLIGenerateFailed:      "Failed to generate commit message: %v",
+	CLIFormatFailed:        "Failed to format output: %v",
+	CLIFormatCommitFailed:  "Failed to format commit message: %v",
+	CLICommitFailed:        "git commit failed: %v",
+	CLICommitted:           "✅ Changes committed",
+	CLICanceled:            "Canceled",
+	CLISelectCandidate:     "Choose the commit message to commit [1-%d] (default 1): ",
+	CLIReadInputFailed:     "Failed to read input: %v",
+	CLIInvalidChoice:       "Invalid choice: %s",
+	CLIElapsed:             "Elapsed: %v",
+	CLIHistoryFailed:       "Failed to read the commit history, not using examples: %v",
+	CLICacheUsage:          "Usage: aimmit cache clear",
+	CLICacheCleared:        "Cleared the cache (%d results): %s",
+	CLICacheFailed:         "Failed to clear the cache: %v",
+	CLIConfigFailed:        "Failed to read the config, using defaults: %v",
+	CLIModelUsage:          "Usage: aimmit model list | verify [model...] | use <model> [--dir directory]",
+	CLIModelFailed:         "Failed to manage models: %v",
+	CLIModelNone:           "No GGUF models found in: %s",
+	CLIModelHeader:         "  NAME\tSIZE\tARCH\tPARAMS\tQUANT\tCONTEXT\tDIRECTORY",
+	CLIModelInvalid:        "%s: cannot read GGUF metadata: %v",
+	CLIModelNotFound:       "Model not found: %s",
+	CLIModelUsed:           "Default model set to %s (saved in %s)",
+	CLIModelVerifyOK:       "OK        %s",
+	CLIModelVerifyMismatch: "MISMATCH  %s (manifest %s, actual %s)",
+	CLIModelVerifyUnlisted: "UNLISTED  %s (not in %s; create it with sha256sum)",
+	CLIModelVerifyMissing:  "MISSING   %s (listed in %s but not found)",
+	CLIModelVerifySummary:  "Verified %d files, %d failed",
+	CLIHeuristicFallback:   "Model generation failed, fell back to heuristics: %s",
+	CLILintViolations:      "Warning: the commit message still violates the rules: %s",
+	CLICommitlintFailed:    "Failed to read the commitlint config, using the default rules: %v",
+	CLICommitlintLoaded:    "Using commitlint config: %s",
+	CLIUnsupportedLocale:   "unsupported locale: %s (available: %s)",
+
+	FlagFormat:           "output format (text, json, conventional)",
+	FlagRepo:             "path to the Git repository",
+	FlagStaged:           "analyze staged changes only",
+	FlagAutoCommit:       "run git commit automatically",
+	FlagDebug:            "enable debug mode",
+	FlagOnlyPrompt:       "only print the prompt",
+	FlagLlamaCPath:       "path to the llama.cpp binaries",
+	FlagModelPath:        "path to the model",
+	FlagBackend:          "inference backend (%s)",
+	FlagServerURL:        "llama-server address",
+	FlagServerEndpoint:   "llama-server endpoint (completion, chat)",
+	FlagSpawnServer:      "start llama-server from llama-c-path in the background when it is not running",
+	FlagModelName:        "model name, used to detect the chat template and sent as the model field by the openai backend (default Qwen3)",
+	FlagChatTemplate:     "chat template (%s), detected from the model name or file name by default",
+	FlagOpenAIBaseURL:    "OpenAI-compatible API address",
+	FlagOpenAIAPIKeyEnv:  "environment variable holding the OpenAI-compatible API key",
+	FlagOpenAITimeout:    "request timeout for the OpenAI-compatible API",
+	FlagOpenAIKwargs:     "turn thinking on or off through chat_template_kwargs (openai backend)",
+	FlagJSONMode:         "ask the backend to reply with a JSON object (openai and ollama backends)",
+	FlagOllamaURL:        "Ollama address",
+	FlagOllamaModel:      "Ollama model tag",
+	FlagOllamaEndpoint:   "Ollama endpoint (generate, chat)",
+	FlagReplayDir:        "replay fixture directory (used by the replay backend and --record)",
+	FlagRecord:           "record backend replies into the replay fixture directory",
+	FlagConstrain:        "constrained decoding mode that guarantees valid JSON (none, json-schema, grammar)",
+	FlagRetries:          "maximum retries when the model reply cannot be parsed",
+	FlagCandidates:       "number of candidate commit messages to generate; choose among them when greater than 1",
+	FlagMapReduce:        "map-reduce mode: summarize each file first, then combine into one commit message (off, auto, always)",
+	FlagStream:           "show generated text live on standard error",
+	FlagTemperature:      "sampling temperature",
+	FlagMaxTokens:        "maximum number of tokens to generate",
+	FlagTopP:             "top-p sampling",
+	FlagTopK:             "top-k sampling",
+	FlagMinP:             "min-p sampling",
+	FlagSeed:             "random seed, randomly chosen when negative (the seed used is recorded in json output)",
+	FlagTimeout:          "timeout for a single generation",
+	FlagThreads:          "number of inference threads, 0 uses the engine default (llama-cli, llama-server and ollama backends)",
+	FlagParallel:         "number of concurrent requests to the backend and the slot count of a spawned llama-server, 0 uses the llama-server slot count, %d for other backends",
+	FlagCtxSize:          "context size, 0 detects it from the model (at most 8192)",
+	FlagLang:             "language of the commit message (%s); bilingual uses an English subject and a Chinese body, bilingual:<lang> sets the body language",
+	FlagThink:            "let the model reason before answering (thinking mode); better for tricky diffs but slower",
+	FlagThinkTokens:      "extra tokens reserved for reasoning in thinking mode",
+	FlagShowThinking:     "show the model's reasoning: printed in debug mode and kept in the streamed output",
+	FlagOfflineHeuristic: "generate the commit message from the changed files with heuristics, without calling a model",
+	FlagFallback:         "fall back to heuristics when model generation fails (missing model file, timeout, etc.)",
+	FlagValidate:         "check the generated commit message against the rules, fixing it or asking the model to regenerate",
+	FlagModelDir:         "additional directory to search for GGUF models",
+	FlagNoCache:          "neither read nor write the cache of generated results",
+	FlagHistory:          "number of recent commits to search for Conventional Commits touching the same files to use as examples, 0 disables examples",
+	FlagLocale:           "interface language (%s), detected from LC_ALL, LC_MESSAGES and LANG by default",
+
+	GitDiffFailed:     "failed to get diff",
+	GitFilesFailed:    "failed to list changed files",
+	GitTopLevelFailed: "failed to find the repository root",
+	GitLogFailed:      "failed to read the commit history",
+
+	AIUnsupportedConstrain:      "unsupported constrained decoding mode: %s",
+	AIPartialUnparsable:         "generation was interrupted and the partial output could not be parsed (%v)",
+	AIUnsupportedBackend:        "unsupported inference backend: %s (available: %s)",
+	AIUnsupportedLang:           "unsupported language: %s (available: %s)",
+	AIUnsupportedTemplate:       "unsupported chat template: %s (available: %s)",
+	AIUnsupportedServerEndpoint: "unsupported llama-server endpoint: %s",
+	AIUnsupportedOllamaEndpoint: "unsupported Ollama endpoint: %s",
+	AIMarshalRequest:            "failed to encode request",
+	AICreateRequest:             "failed to create request",
+	AIRequestTimeout:            "request to %s timed out",
+	AIRequestFailed:             "request to %s failed",
+	AIHTTPStatus:                "request to %s failed: HTTP %d: %s",
+	AIReadResponse:              "failed to read response",
+	AIParseResponse:             "failed to parse response",
+	AIReadStream:                "failed to read streaming response",
+	AIParseStream:               "failed to parse streaming response",
+	AIMarshalSchema:             "failed to encode JSON Schema",
+	AINoChoices:                 "response contains no choices",
+	AIServerNotRunning:          "llama-server is not running: %s",
+	AIParseServerURL:            "failed to parse llama-server address",
+	AIStartServer:               "failed to start llama-server",
+	AIServerReadyTimeout:        "timed out waiting for llama-server to become ready",
+	AISummarizeFile:             "failed to summarize changes in %s",
+	AIOllamaFailed:              "Ollama request failed",
+	AIOpenAIFailed:              "OpenAI-compatible API request failed",
+	AIReplayNotFound:            "replay fixture not found: %s (record one with --record)",
+	AIReplayRead:                "failed to read replay fixture",
+	AIReplayParse:               "failed to parse replay fixture",
+	AIReplayMarshal:             "failed to encode replay fixture",
+	AIReplayMkdir:               "failed to create replay fixture directory",
+	AIReplaySave:                "failed to save replay fixture",
+	AICreatePipe:                "failed to create output pipe",
+	AIProcessStart:              "failed to start %s",
+	AIProcessTimeout:            "%s timed out",
+	AIProcessExit:               "%s exited abnormally",
+	AIProcessRead:               "failed to read output of %s",
+	AITokenizeFailed:            "llama-tokenize failed",
+	AITokenizeParse:             "could not parse llama-tokenize output",
+	AINoJSON:                    "no valid JSON found",
+	AIInvalidJSON:               "invalid JSON",
+	AISchemaViolation:           "JSON does not match the commit message format",
+	AIFieldType:                 "field %s should be of type %s",
+	AIMissingField:              "missing field %s",
+	AIPromptTemplateLoad:        "failed to load prompt template %s",
+	AIPromptTemplateExec:        "failed to render prompt template %s",
+	AIUnknownFile:               "unknown file",
+
+	AIDebugGGUFFailed:      "Failed to read GGUF metadata: %v",
+	AIDebugUsage:           "Token usage: prompt=%d completion=%d",
+	AIDebugModelInfo:       "Backend: %s, architecture: %s, context length: %d (using %d), chat template: %s",
+	AIDebugUnknown:         "unknown",
+	AIDebugSalvaged:        "Generation was interrupted, recovered from partial output: %v",
+	AIDebugRetry:           "Parse failed (attempt %d): %v, asking the model to fix it",
+	AIDebugCandidateFailed: "Candidate %d failed: %v",
+	AIDebugStartServer:     "Starting llama-server: %s",
+	AIDebugFileSummary:     "File summary %s: %s",
+	AIDebugTokenBudget:     "Token budget: prompt %d, diff %d",
+	AIDebugPromptTemplate:  "Using prompt template: %s",
+	AIDebugCacheHit:        "Using cached result: %s",
+	AIDebugCacheSkipped:    "Cannot compute the cache key, not using the cache: %v",
+	AIDebugCacheFailed:     "Failed to access the cache: %v",
+	AIDebugThinking:        "Reasoning:\n%s",
+	AIDebugLintRetry:       "Rule violations (attempt %d): %s, asking the model to regenerate",
+
+	ConfigDir:   "failed to find the config directory",
+	ConfigRead:  "failed to read config file %s",
+	ConfigWrite: "failed to write config file %s",
+
+	ModelsScan:     "failed to scan model directory %s",
+	ModelsManifest: "failed to read manifest %s",
+	ModelsHash:     "failed to hash %s",
+
+	GGUFNotGGUF:       "not a GGUF file",
+	GGUFOpen:          "failed to open the model file",
+	GGUFRead:          "failed to read %s",
+	GGUFVersion:       "unsupported GGUF version: %d",
+	GGUFTruncated:     "file is truncated",
+	GGUFStringTooLong: "string is too long: %d",
+	GGUFUnknownType:   "unknown metadata type: %d",
+
+	CacheDir:      "failed to find the cache directory",
+	CacheRead:     "failed to read the cache",
+	CacheWrite:    "failed to write the cache",
+	CacheClear:    "failed to clear the cache",
+	CacheHashFile: "failed to hash file %s",
+
+	LintTypeEmpty:          "type is empty",
+	LintTypeEnum:           "type %q is not one of the allowed types (%s)",
+	LintScopeCharset:       "scope %q may only contain lowercase letters, digits and ._/-",
+	LintScopeEnum:          "scope %q violates the scope-enum rule (%s)",
+	LintSubjectEmpty:       "subject is empty",
+	LintSubjectFullStop:    "subject must not end with a period",
+	LintSubjectCaseNever:   "subject must not use these cases: %s",
+	LintSubjectCaseAlways:  "subject must use one of these cases: %s",
+	LintSubjectMaxLength:   "subject has %d characters, the limit is %d",
+	LintHeaderMaxLength:    "header has %d characters, the limit is %d",
+	LintBodyMaxLineLength:  "body line %d has %d characters, the limit is %d",
+	LintConfigRead:         "failed to read commitlint config %s",
+	LintConfigParse:        "failed to parse commitlint config %s",
+	LintConfigRule:         "invalid value for rule %s",
+	LintConfigUnterminated: "unterminated string, comment or object",
+	LintYAMLSyntax:         "unsupported YAML: %s",
+
+	SummarizerUnsupportedFormat: "unsupported output format: %s",
+	SummarizerMarshalJSON:       "failed to encode JSON",
+}
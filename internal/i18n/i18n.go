@@ -0,0 +1,164 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 支持的界面语言
+const (
+	Zh = "zh" // 中文
+	En = "en" // 英文
+)
+
+// DefaultLocale 是无法从环境变量判断语言时使用的界面语言
+const DefaultLocale = Zh
+
+// Code 是消息的编号，同一编号在各语言的消息目录中对应同一条消息
+type Code string
+
+// catalogs 是各语言的消息目录，消息是fmt格式字符串
+var catalogs = map[string]map[Code]string{
+	Zh: zhMessages,
+	En: enMessages,
+}
+
+var (
+	mu     sync.RWMutex
+	locale = DefaultLocale
+)
+
+// Locales 返回支持的界面语言
+func Locales() []string {
+	return []string{Zh, En}
+}
+
+// SetLocale 设置当前的界面语言，不支持的语言会被忽略
+func SetLocale(l string) {
+	l = Normalize(l)
+	if _, ok := catalogs[l]; !ok {
+		return
+	}
+	mu.Lock()
+	locale = l
+	mu.Unlock()
+}
+
+// Locale 返回当前的界面语言
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// Normalize 把zh_CN.UTF-8、en-US这类语言标记转换为支持的界面语言，无法识别时返回空字符串
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	// 去掉编码和修饰符，例如 zh_CN.UTF-8@latin
+	if i := strings.IndexAny(tag, ".@"); i != -1 {
+		tag = tag[:i]
+	}
+	switch {
+	case tag == "" || tag == "c" || tag == "posix":
+		return ""
+	case strings.HasPrefix(tag, "zh"):
+		return Zh
+	default:
+		// 其他语言暂时没有翻译，使用英文
+		return En
+	}
+}
+
+// DetectLocale 按LC_ALL、LC_MESSAGES、LANG的优先级判断界面语言
+func DetectLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if l := Normalize(value); l != "" {
+				return l
+			}
+			return DefaultLocale
+		}
+	}
+	return DefaultLocale
+}
+
+// T 用当前的界面语言渲染消息
+func T(code Code, args ...interface{}) string {
+	return Render(Locale(), code, args...)
+}
+
+// Render 用指定的界面语言渲染消息，缺少翻译时依次使用默认语言和消息编号
+func Render(l string, code Code, args ...interface{}) string {
+	format, ok := catalogs[l][code]
+	if !ok {
+		format, ok = catalogs[DefaultLocale][code]
+	}
+	if !ok {
+		format = string(code)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Localizer 由可以用指定语言渲染自身的错误实现
+type Localizer interface {
+	Localize(locale string) string
+}
+
+// Localize 用指定的界面语言渲染错误，不支持多语言的错误原样返回
+func Localize(err error, l string) string {
+	if localizer, ok := err.(Localizer); ok {
+		return localizer.Localize(l)
+	}
+	return err.Error()
+}
+
+// Error 是带编号的错误，可以用任意界面语言渲染
+type Error struct {
+	Code Code          // 消息编号
+	Args []interface{} // 消息参数
+	Err  error         // 导致该错误的原因，可以为空
+}
+
+// New 创建带编号的错误
+func New(code Code, args ...interface{}) *Error {
+	return &Error{Code: code, Args: args}
+}
+
+// Wrap 创建带编号的错误，err作为原因附加在消息之后
+func Wrap(err error, code Code, args ...interface{}) *Error {
+	return &Error{Code: code, Args: args, Err: err}
+}
+
+// Error 用当前的界面语言返回错误描述
+func (e *Error) Error() string {
+	return e.Localize(Locale())
+}
+
+// Localize 用指定的界面语言返回错误描述
+func (e *Error) Localize(l string) string {
+	msg := Render(l, e.Code, e.Args...)
+	if e.Err != nil {
+		msg += ": " + Localize(e.Err, l)
+	}
+	return msg
+}
+
+// Unwrap 返回导致该错误的原因
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf 返回错误链中第一个带编号的错误的编号，没有时返回空字符串
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package i18n
+
+// zhMessages 是中文消息目录
+var zhMessages = map[Code]string{
+	CLIGetDiffFailed:       "获取差异信息失败: %v",
+	CLINoChanges:           "没有检测到任何更改",
+	CLIGenerating:          "正在生成commit message...",
+	CLIGenerateFailed:      "生成commit message失败: %v",
+	CLIFormatFailed:        "格式化输出失败: %v",
+	CLIFormatCommitFailed:  "格式化commit message失败: %v",
+	CLICommitFailed:        "执行git commit失败: %v",
+	CLICommitted:           "✅ 已成功提交更改",
+	CLICanceled:            "已取消",
+	CLISelectCandidate:     "请选择要提交的commit message [1-%d]（默认1）: ",
+	CLIReadInputFailed:     "读取输入失败: %v",
+	CLIInvalidChoice:       "无效的选择: %s",
+	CLIElapsed:             "执行时间: %v",
+	CLIHistoryFailed:       "读取提交历史失败，不使用示例: %v",
+	CLICacheUsage:          "用法: aimmit cache clear",
+	CLICacheCleared:        "已清除缓存（%d条生成结果）: %s",
+	CLICacheFailed:         "清除缓存失败: %v",
+	CLIConfigFailed:        "读取配置失败，使用默认设置: %v",
+	CLIModelUsage:          "用法: aimmit model list | verify [模型...] | use <模型> [--dir 目录]",
+	CLIModelFailed:         "管理模型失败: %v",
+	CLIModelNone:           "在以下目录中没有找到GGUF模型: %s",
+	CLIModelHeader:         "  名称\t大小\t架构\t参数\t量化\t上下文\t目录",
+	CLIModelInvalid:        "%s: 无法读取GGUF元数据: %v",
+	CLIModelNotFound:       "没有找到模型: %s",
+	CLIModelUsed:           "默认模型已设置为%s（保存在%s）",
+	CLIModelVerifyOK:       "通过    %s",
+	CLIModelVerifyMismatch: "不一致  %s（清单中为%s，实际为%s）",
+	CLIModelVerifyUnlisted: "未记录  %s（%s中没有该文件，可以用sha256sum生成）",
+	CLIModelVerifyMissing:  "缺失    %s（%s中记录的文件不存在）",
+	CLIModelVerifySummary:  "共校验%d个文件，%d个未通过",
+	CLIHeuristicFallback:   "模型生成失败，已改用启发式规则生成: %s",
+	CLILintViolations:      "警告：commit message仍不符合规范: %s",
+	CLICommitlintFailed:    "读取commitlint配置失败，使用默认规则: %v",
+	CLICommitlintLoaded:    "使用commitlint配置: %s",
+	CLIUnsupportedLocale:   "不支持的界面语言: %s (可选: %s)",
+
+	FlagFormat:           "输出格式 (text, json, conventional)",
+	FlagRepo:             "Git仓库路径",
+	FlagStaged:           "是否只分析已暂存的更改",
+	FlagAutoCommit:       "是否自动执行git commit",
+	FlagDebug:            "是否开启debug模式",
+	FlagOnlyPrompt:       "只显示prompt",
+	FlagLlamaCPath:       "llama.cpp项目路径",
+	FlagModelPath:        "模型路径",
+	FlagBackend:          "推理后端 (%s)",
+	FlagServerURL:        "llama-server地址",
+	FlagServerEndpoint:   "llama-server接口 (completion, chat)",
+	FlagSpawnServer:      "llama-server未运行时是否从llama-c-path自动启动并在后台常驻",
+	FlagModelName:        "模型名称，用于识别对话模板，openai后端会作为model字段发送（默认为Qwen3）",
+	FlagChatTemplate:     "对话模板 (%s)，默认根据模型名称或模型文件名自动识别",
+	FlagOpenAIBaseURL:    "OpenAI兼容接口地址",
+	FlagOpenAIAPIKeyEnv:  "读取OpenAI兼容接口API Key的环境变量",
+	FlagOpenAITimeout:    "OpenAI兼容接口的请求超时",
+	FlagOpenAIKwargs:     "是否通过chat_template_kwargs开启或关闭思考模式（openai后端）",
+	FlagJSONMode:         "是否要求后端以JSON对象格式返回（openai、ollama后端）",
+	FlagOllamaURL:        "Ollama地址",
+	FlagOllamaModel:      "Ollama模型标签",
+	FlagOllamaEndpoint:   "Ollama接口 (generate, chat)",
+	FlagReplayDir:        "回放数据目录（replay后端和--record使用）",
+	FlagRecord:           "是否把后端的回复录制到回放数据目录",
+	FlagConstrain:        "约束解码模式，保证输出为合法JSON (none, json-schema, grammar)",
+	FlagRetries:          "模型回复无法解析时的最大重试次数",
+	FlagCandidates:       "生成的候选commit message数量，大于1时可以从中选择",
+	FlagMapReduce:        "分段总结模式：先逐个文件总结变更，再合并生成commit message (off, auto, always)",
+	FlagStream:           "是否在标准错误输出中实时显示模型生成的内容",
+	FlagTemperature:      "生成温度",
+	FlagMaxTokens:        "最大生成的token数",
+	FlagTopP:             "top-p采样",
+	FlagTopK:             "top-k采样",
+	FlagMinP:             "min-p采样",
+	FlagSeed:             "随机种子，小于0时随机生成（实际使用的种子会记录在json格式的输出中）",
+	FlagTimeout:          "单次生成的超时时间",
+	FlagThreads:          "推理线程数，为0时使用推理引擎的默认值（llama-cli、llama-server、ollama后端）",
+	FlagParallel:         "同时发给后端的请求数，也是自动启动llama-server时的slot数，为0时使用llama-server的slot数，其他后端为%d",
+	FlagCtxSize:          "上下文长度，为0时根据模型自动确定（最多8192）",
+	FlagLang:             "commit message使用的语言 (%s)，bilingual为英文主题、中文正文，bilingual:<语言>指定正文的语言",
+	FlagThink:            "允许模型在回答前先推理（思考模式），对复杂的diff效果更好但更慢",
+	FlagThinkTokens:      "思考模式下为推理内容额外预留的token数",
+	FlagShowThinking:     "显示模型的推理内容：debug模式下输出推理内容，实时输出中保留推理过程",
+	FlagOfflineHeuristic: "不调用模型，根据修改的文件用启发式规则生成commit message",
+	FlagFallback:         "模型生成失败（模型文件不存在、超时等）时改用启发式规则生成",
+	FlagValidate:         "检查生成的commit message是否符合规范，自动修正或要求模型重新生成",
+	FlagModelDir:         "额外查找GGUF模型的目录",
+	FlagNoCache:          "不读取也不写入生成结果的缓存",
+	FlagHistory:          "从最近多少个提交中选出修改过相同文件的约定式提交作为示例，为0时不使用示例",
+	FlagLocale:           "界面语言 (%s)，默认根据LC_ALL、LC_MESSAGES、LANG环境变量判断",
+
+	GitDiffFailed:     "获取diff失败",
+	GitFilesFailed:    "获取修改文件列表失败",
+	GitTopLevelFailed: "获取仓库根目录失败",
+	GitLogFailed:      "获取提交历史失败",
+
+	AIUnsupportedConstrain:      "不支持的约束解码模式: %s",
+	AIPartialUnparsable:         "生成中断，部分输出无法解析（%v）",
+	AIUnsupportedBackend:        "不支持的推理后端: %s (可选: %s)",
+	AIUnsupportedLang:           "不支持的语言: %s (可选: %s)",
+	AIUnsupportedTemplate:       "不支持的对话模板: %s (可选: %s)",
+	AIUnsupportedServerEndpoint: "不支持的llama-server接口: %s",
+	AIUnsupportedOllamaEndpoint: "不支持的Ollama接口: %s",
+	AIMarshalRequest:            "序列化请求失败",
+	AICreateRequest:             "创建请求失败",
+	AIRequestTimeout:            "请求%s超时",
+	AIRequestFailed:             "请求%s失败",
+	AIHTTPStatus:                "请求%s失败: HTTP %d: %s",
+	AIReadResponse:              "读取响应失败",
+	AIParseResponse:             "解析响应失败",
+	AIReadStream:                "读取流式响应失败",
+	AIParseStream:               "解析流式响应失败",
+	AIMarshalSchema:             "序列化JSON Schema失败",
+	AINoChoices:                 "响应中没有任何结果",
+	AIServerNotRunning:          "llama-server未运行: %s",
+	AIParseServerURL:            "解析llama-server地址失败",
+	AIStartServer:               "启动llama-server失败",
+	AIServerReadyTimeout:        "等待llama-server就绪超时",
+	AISummarizeFile:             "总结文件%s的变更失败",
+	AIOllamaFailed:              "调用Ollama失败",
+	AIOpenAIFailed:              "调用OpenAI兼容接口失败",
+	AIReplayNotFound:            "未找到回放数据: %s（可使用--record录制）",
+	AIReplayRead:                "读取回放数据失败",
+	AIReplayParse:               "解析回放数据失败",
+	AIReplayMarshal:             "序列化回放数据失败",
+	AIReplayMkdir:               "创建回放数据目录失败",
+	AIReplaySave:                "保存回放数据失败",
+	AICreatePipe:                "创建输出管道失败",
+	AIProcessStart:              "启动%s失败",
+	AIProcessTimeout:            "执行%s超时",
+	AIProcessExit:               "%s异常退出",
+	AIProcessRead:               "读取%s的输出失败",
+	AITokenizeFailed:            "调用llama-tokenize失败",
+	AITokenizeParse:             "无法解析llama-tokenize的输出",
+	AINoJSON:                    "没有找到有效的JSON",
+	AIInvalidJSON:               "JSON格式无效",
+	AISchemaViolation:           "JSON不符合提交信息格式",
+	AIFieldType:                 "字段%s应为%s类型",
+	AIMissingField:              "缺少%s字段",
+	AIPromptTemplateLoad:        "加载提示词模板%s失败",
+	AIPromptTemplateExec:        "渲染提示词模板%s失败",
+	AIUnknownFile:               "未知文件",
+
+	AIDebugGGUFFailed:      "读取GGUF元数据失败: %v",
+	AIDebugUsage:           "token用量: prompt=%d completion=%d",
+	AIDebugModelInfo:       "推理后端: %s, 模型架构: %s, 上下文长度: %d（使用%d）, 对话模板: %s",
+	AIDebugUnknown:         "未知",
+	AIDebugSalvaged:        "生成中断，已从部分输出中恢复: %v",
+	AIDebugRetry:           "解析失败（第%d次）: %v，要求模型修复后重试",
+	AIDebugCandidateFailed: "候选%d生成失败: %v",
+	AIDebugStartServer:     "启动llama-server: %s",
+	AIDebugFileSummary:     "文件摘要 %s: %s",
+	AIDebugTokenBudget:     "token预算: 提示词%d, 差异详情%d",
+	AIDebugPromptTemplate:  "使用提示词模板: %s",
+	AIDebugCacheHit:        "使用缓存的生成结果: %s",
+	AIDebugCacheSkipped:    "无法计算缓存键，不使用缓存: %v",
+	AIDebugCacheFailed:     "读写缓存失败: %v",
+	AIDebugThinking:        "推理内容:\n%s",
+	AIDebugLintRetry:       "不符合规范（第%d次）: %s，要求模型重新生成",
+
+	ConfigDir:   "获取配置目录失败",
+	ConfigRead:  "读取配置文件%s失败",
+	ConfigWrite: "写入配置文件%s失败",
+
+	ModelsScan:     "扫描模型目录%s失败",
+	ModelsManifest: "读取清单%s失败",
+	ModelsHash:     "计算%s的哈希失败",
+
+	GGUFNotGGUF:       "不是GGUF文件",
+	GGUFOpen:          "打开模型文件失败",
+	GGUFRead:          "读取%s失败",
+	GGUFVersion:       "不支持的GGUF版本: %d",
+	GGUFTruncated:     "文件被截断",
+	GGUFStringTooLong: "字符串过长: %d",
+	GGUFUnknownType:   "未知的元数据类型: %d",
+
+	CacheDir:      "获取缓存目录失败",
+	CacheRead:     "读取缓存失败",
+	CacheWrite:    "写入缓存失败",
+	CacheClear:    "清除缓存失败",
+	CacheHashFile: "计算文件%s的哈希失败",
+
+	LintTypeEmpty:          "类型为空",
+	LintTypeEnum:           "类型%q不是允许的类型（%s）",
+	LintScopeCharset:       "范围%q只能包含小写字母、数字和._/-",
+	LintScopeEnum:          "范围%q不符合scope-enum规则（%s）",
+	LintSubjectEmpty:       "主题为空",
+	LintSubjectFullStop:    "主题不能以句号结尾",
+	LintSubjectCaseNever:   "主题不能使用以下大小写格式: %s",
+	LintSubjectCaseAlways:  "主题必须使用以下大小写格式之一: %s",
+	LintSubjectMaxLength:   "主题有%d个字符，不能超过%d个字符",
+	LintHeaderMaxLength:    "第一行有%d个字符，不能超过%d个字符",
+	LintBodyMaxLineLength:  "正文第%d行有%d个字符，每行不能超过%d个字符",
+	LintConfigRead:         "读取commitlint配置%s失败",
+	LintConfigParse:        "解析commitlint配置%s失败",
+	LintConfigRule:         "规则%s的格式不正确",
+	LintConfigUnterminated: "字符串、注释或对象没有结束",
+	LintYAMLSyntax:         "无法解析的YAML: %s",
+
+	SummarizerUnsupportedFormat: "不支持的输出格式: %s",
+	SummarizerMarshalJSON:       "序列化JSON失败",
+}
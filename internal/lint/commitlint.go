@@ -0,0 +1,340 @@
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// ConfigFiles 是按优先级查找的commitlint配置文件，package.json中读取commitlint字段
+var ConfigFiles = []string{
+	".commitlintrc",
+	".commitlintrc.json",
+	".commitlintrc.yaml",
+	".commitlintrc.yml",
+	".commitlintrc.js",
+	".commitlintrc.cjs",
+	".commitlintrc.mjs",
+	".commitlintrc.ts",
+	"commitlint.config.js",
+	"commitlint.config.cjs",
+	"commitlint.config.mjs",
+	"commitlint.config.ts",
+	"package.json",
+}
+
+// FindConfig 返回dir中的commitlint配置文件，没有时返回空字符串
+func FindConfig(dir string) string {
+	for _, name := range ConfigFiles {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		if name == "package.json" && !hasPackageConfig(path) {
+			continue
+		}
+		return path
+	}
+	return ""
+}
+
+// LoadRules 返回dir中的commitlint配置覆盖默认规则后的规则，以及使用的配置文件。
+// 只读取配置文件中的rules，不解析extends引用的共享配置
+func LoadRules(dir string, types []string) (*Rules, string, error) {
+	rules := DefaultRules(types)
+	path := FindConfig(dir)
+	if path == "" {
+		return rules, "", nil
+	}
+
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, path, err
+	}
+	if err := rules.apply(config); err != nil {
+		return nil, path, i18n.Wrap(err, i18n.LintConfigParse, path)
+	}
+	return rules, path, nil
+}
+
+// hasPackageConfig 判断package.json中是否有commitlint字段，
+// 无法解析时只要提到commitlint就认为有，由readConfig报告错误
+func hasPackageConfig(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(data, &pkg) != nil {
+		return strings.Contains(string(data), `"commitlint"`)
+	}
+	_, ok := pkg["commitlint"]
+	return ok
+}
+
+// readConfig 按文件格式读取配置：JSON、YAML或JavaScript/TypeScript中的rules对象
+func readConfig(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.LintConfigRead, path)
+	}
+
+	var config map[string]interface{}
+	name := filepath.Base(path)
+	switch ext := filepath.Ext(name); {
+	case name == "package.json":
+		var pkg struct {
+			Commitlint map[string]interface{} `json:"commitlint"`
+		}
+		err = json.Unmarshal(data, &pkg)
+		config = pkg.Commitlint
+	case ext == ".json":
+		err = json.Unmarshal(data, &config)
+	case ext == ".yaml" || ext == ".yml":
+		config, err = parseYAMLConfig(string(data))
+	case ext == ".js" || ext == ".cjs" || ext == ".mjs" || ext == ".ts":
+		config, err = parseJSConfig(string(data))
+	default:
+		// 没有扩展名的.commitlintrc可以是JSON或YAML
+		if err = json.Unmarshal(data, &config); err != nil {
+			config, err = parseYAMLConfig(string(data))
+		}
+	}
+	if err != nil {
+		return nil, i18n.Wrap(err, i18n.LintConfigParse, path)
+	}
+	return config, nil
+}
+
+// apply 用配置中的rules覆盖规则。每条规则形如[级别, "always"|"never", 值]，级别为0时关闭该规则
+func (r *Rules) apply(config map[string]interface{}) error {
+	ruleConfigs, _ := config["rules"].(map[string]interface{})
+	for name, value := range ruleConfigs {
+		ruleConfig, ok := value.([]interface{})
+		if !ok || len(ruleConfig) == 0 {
+			return i18n.New(i18n.LintConfigRule, name)
+		}
+		level, ok := ruleConfig[0].(float64)
+		if !ok {
+			return i18n.New(i18n.LintConfigRule, name)
+		}
+		never := len(ruleConfig) > 1 && ruleConfig[1] == "never"
+		var arg interface{}
+		if len(ruleConfig) > 2 {
+			arg = ruleConfig[2]
+		}
+
+		var err error
+		switch name {
+		case RuleTypeEnum:
+			var types []string
+			types, err = ruleStrings(name, level, arg)
+			if never {
+				// never时列出的是不允许的类型
+				types = exclude(r.Types, types)
+			}
+			r.Types = types
+		case RuleScopeEnum:
+			r.Scopes, err = ruleStrings(name, level, arg)
+			r.ScopesNever = never
+		case RuleSubjectCase:
+			r.SubjectCase, err = ruleStrings(name, level, arg)
+			r.SubjectCaseNever = never
+		case RuleHeaderMaxLength:
+			r.HeaderMaxLength, err = ruleInt(name, level, arg)
+		case RuleSubjectMaxLength:
+			r.SubjectMaxLength, err = ruleInt(name, level, arg)
+		case RuleBodyMaxLineLength:
+			r.BodyMaxLineLength, err = ruleInt(name, level, arg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleStrings 读取字符串或字符串列表形式的规则值，规则关闭时返回nil
+func ruleStrings(name string, level float64, arg interface{}) ([]string, error) {
+	if level == 0 {
+		return nil, nil
+	}
+	switch v := arg.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, i18n.New(i18n.LintConfigRule, name)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, i18n.New(i18n.LintConfigRule, name)
+}
+
+// ruleInt 读取数字形式的规则值，规则关闭时返回0
+func ruleInt(name string, level float64, arg interface{}) (int, error) {
+	if level == 0 {
+		return 0, nil
+	}
+	n, ok := arg.(float64)
+	if !ok {
+		return 0, i18n.New(i18n.LintConfigRule, name)
+	}
+	return int(n), nil
+}
+
+// exclude 返回list中不在excluded中的元素
+func exclude(list, excluded []string) []string {
+	result := []string{}
+	for _, item := range list {
+		if !contains(excluded, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseJSConfig 从JavaScript/TypeScript配置中取出rules对象字面量，转换为JSON后解析。
+// 支持单引号字符串、不带引号的键、末尾的逗号、注释和RuleConfigSeverity枚举
+func parseJSConfig(src string) (map[string]interface{}, error) {
+	tokens, err := tokenizeJS(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].text != "rules" || tokens[i+1].text != ":" || tokens[i+2].text != "{" {
+			continue
+		}
+		depth := 0
+		for j := i + 2; j < len(tokens); j++ {
+			if tokens[j].string {
+				continue
+			}
+			switch tokens[j].text {
+			case "{", "[":
+				depth++
+			case "}", "]":
+				depth--
+			}
+			if depth == 0 {
+				var rules map[string]interface{}
+				if err := json.Unmarshal([]byte(jsToJSON(tokens[i+2:j+1])), &rules); err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"rules": rules}, nil
+			}
+		}
+		return nil, i18n.New(i18n.LintConfigUnterminated)
+	}
+	// 没有rules时只使用默认规则
+	return map[string]interface{}{}, nil
+}
+
+// jsToken 是JavaScript源码中的一个记号
+type jsToken struct {
+	text   string // 记号的内容，字符串为去掉引号后的内容
+	string bool   // 是否为字符串字面量
+}
+
+// severities 是@commitlint/types中RuleConfigSeverity枚举的值
+var severities = map[string]string{
+	"RuleConfigSeverity.Disabled": "0",
+	"RuleConfigSeverity.Warning":  "1",
+	"RuleConfigSeverity.Error":    "2",
+}
+
+// tokenizeJS 把源码拆分为标识符、数字、字符串和标点，跳过注释和空白
+func tokenizeJS(src string) ([]jsToken, error) {
+	tokens := []jsToken{}
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				end = len(src) - i
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return nil, i18n.New(i18n.LintConfigUnterminated)
+			}
+			i += end + 4
+		case ch == '\'' || ch == '"' || ch == '`':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != ch; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, i18n.New(i18n.LintConfigUnterminated)
+			}
+			tokens = append(tokens, jsToken{text: sb.String(), string: true})
+			i = j + 1
+		case isIdentByte(ch):
+			j := i
+			for j < len(src) && (isIdentByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, jsToken{text: src[i:j]})
+			i = j
+		default:
+			tokens = append(tokens, jsToken{text: string(ch)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// isIdentByte 判断ch是否可以出现在标识符或数字中
+func isIdentByte(ch byte) bool {
+	return ch == '_' || ch == '$' || ch == '-' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// jsToJSON 把对象字面量的记号转换为JSON：给键和标识符加引号，去掉末尾的逗号
+func jsToJSON(tokens []jsToken) string {
+	var sb strings.Builder
+	for i, token := range tokens {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].text
+		}
+		switch {
+		case token.string:
+			data, _ := json.Marshal(token.text)
+			sb.Write(data)
+		case token.text == "," && (next == "}" || next == "]"):
+		case severities[token.text] != "":
+			sb.WriteString(severities[token.text])
+		case token.text == "true" || token.text == "false" || token.text == "null" || isNumber(token.text) && next != ":":
+			sb.WriteString(token.text)
+		case isIdentByte(token.text[0]):
+			data, _ := json.Marshal(token.text)
+			sb.Write(data)
+		default:
+			sb.WriteString(token.text)
+		}
+	}
+	return sb.String()
+}
+
+// isNumber 判断s是否为数字
+func isNumber(s string) bool {
+	var n float64
+	return json.Unmarshal([]byte(s), &n) == nil
+}
@@ -0,0 +1,294 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// testTypes 是测试使用的默认类型
+var testTypes = []string{"feat", "fix", "docs", "chore"}
+
+// hasCode 判断错误链中是否有编号为code的错误
+func hasCode(err error, code i18n.Code) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*i18n.Error); ok && e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// writeConfig 在临时目录中写入配置文件，返回目录
+func writeConfig(t *testing.T, name, content string) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    func(r *Rules) // 在默认规则上修改出期望的规则
+	}{
+		{
+			name: "json",
+			file: ".commitlintrc.json",
+			content: `{
+  "extends": ["@commitlint/config-conventional"],
+  "rules": {
+    "type-enum": [2, "always", ["feat", "fix", "perf"]],
+    "scope-enum": [2, "always", ["api", "cli"]],
+    "header-max-length": [2, "always", 72],
+    "subject-max-length": [0, "always", 30],
+    "subject-case": [2, "always", "lower-case"],
+    "footer-leading-blank": [1, "always"]
+  }
+}`,
+			want: func(r *Rules) {
+				r.Types = []string{"feat", "fix", "perf"}
+				r.Scopes = []string{"api", "cli"}
+				r.HeaderMaxLength = 72
+				r.SubjectMaxLength = 0
+				r.SubjectCase = []string{"lower-case"}
+				r.SubjectCaseNever = false
+			},
+		},
+		{
+			name: "yaml with comments, quoted keys and flow lists",
+			file: ".commitlintrc.yaml",
+			content: `# commitlint配置
+extends:
+  - '@commitlint/config-conventional'
+rules:
+  # 不允许的类型
+  'type-enum': [2, never, [docs, chore]]  # 行尾注释
+  "scope-enum": [2, 'always', ["api", 'web # ui']]
+  subject-max-length: [2, always, 60]
+  body-max-line-length: [0]
+`,
+			want: func(r *Rules) {
+				r.Types = []string{"feat", "fix"}
+				r.Scopes = []string{"api", "web # ui"}
+				r.SubjectMaxLength = 60
+				r.BodyMaxLineLength = 0
+			},
+		},
+		{
+			name: "yml with block and nested sequences",
+			file: ".commitlintrc.yml",
+			content: `---
+rules:
+  scope-enum:
+    - 2
+    - never
+    - - deps
+      - release
+  subject-case:
+  - 2
+  - always
+  - - lower-case
+    - kebab-case
+  header-max-length:
+    - 2
+    - always
+    - 100
+`,
+			want: func(r *Rules) {
+				r.Scopes = []string{"deps", "release"}
+				r.ScopesNever = true
+				r.SubjectCase = []string{"lower-case", "kebab-case"}
+				r.SubjectCaseNever = false
+				r.HeaderMaxLength = 100
+			},
+		},
+		{
+			name:    "commitlintrc without extension",
+			file:    ".commitlintrc",
+			content: "rules:\n  type-enum: [2, always, [feat]]\n",
+			want: func(r *Rules) {
+				r.Types = []string{"feat"}
+			},
+		},
+		{
+			name:    "package.json",
+			file:    "package.json",
+			content: `{"name": "demo", "commitlint": {"rules": {"type-enum": [2, "always", ["feat", "fix"]], "subject-case": [0]}}}`,
+			want: func(r *Rules) {
+				r.Types = []string{"feat", "fix"}
+				r.SubjectCase = nil
+				r.SubjectCaseNever = false
+			},
+		},
+		{
+			name:    "package.json without commitlint",
+			file:    "package.json",
+			content: `{"name": "demo"}`,
+			want:    func(r *Rules) {},
+		},
+		{
+			name: "commitlint.config.js",
+			file: "commitlint.config.js",
+			content: `// @ts-check
+const { RuleConfigSeverity } = require('@commitlint/types');
+
+/* 共享配置之外的规则 */
+module.exports = {
+  extends: ['@commitlint/config-conventional'],
+  rules: {
+    'type-enum': [RuleConfigSeverity.Error, 'always', ['feat', "fix", ` + "`build`" + `,]],
+    'scope-enum': [RuleConfigSeverity.Disabled, 'always', ['ignored']],
+    "subject-max-length": [2, 'always', 40],
+    'header-max-length': [RuleConfigSeverity.Warning, 'always', 88], // 警告也生效
+  },
+};
+`,
+			want: func(r *Rules) {
+				r.Types = []string{"feat", "fix", "build"}
+				r.Scopes = nil
+				r.SubjectMaxLength = 40
+				r.HeaderMaxLength = 88
+			},
+		},
+		{
+			name:    "js without rules",
+			file:    "commitlint.config.js",
+			content: "module.exports = { extends: ['@commitlint/config-conventional'] };\n",
+			want:    func(r *Rules) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfig(t, tt.file, tt.content)
+			rules, path, err := LoadRules(dir, testTypes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := DefaultRules(testTypes)
+			tt.want(want)
+			if !reflect.DeepEqual(rules, want) {
+				t.Errorf("got %+v\nwant %+v", rules, want)
+			}
+			if tt.file == "package.json" && tt.content == `{"name": "demo"}` {
+				if path != "" {
+					t.Errorf("package.json without commitlint should not be used, got %s", path)
+				}
+			} else if filepath.Base(path) != tt.file {
+				t.Errorf("got config %s, want %s", path, tt.file)
+			}
+		})
+	}
+}
+
+func TestLoadRulesDefault(t *testing.T) {
+	rules, path, err := LoadRules(t.TempDir(), testTypes)
+	if err != nil || path != "" {
+		t.Fatalf("got path %q, err %v", path, err)
+	}
+	if !reflect.DeepEqual(rules, DefaultRules(testTypes)) {
+		t.Errorf("got %+v, want the default rules", rules)
+	}
+}
+
+func TestLoadRulesMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		code    i18n.Code // 除LintConfigParse之外期望的错误
+	}{
+		{name: "json syntax", file: ".commitlintrc.json", content: `{"rules": {"type-enum": [2, "always", ["feat"]]}`},
+		{name: "rule is not a list", file: ".commitlintrc.json", content: `{"rules": {"type-enum": 2}}`, code: i18n.LintConfigRule},
+		{name: "empty rule", file: ".commitlintrc.json", content: `{"rules": {"type-enum": []}}`, code: i18n.LintConfigRule},
+		{name: "level is not a number", file: ".commitlintrc.json", content: `{"rules": {"type-enum": ["error", "always", ["feat"]]}}`, code: i18n.LintConfigRule},
+		{name: "enum is not a list of strings", file: ".commitlintrc.json", content: `{"rules": {"scope-enum": [2, "always", [1, 2]]}}`, code: i18n.LintConfigRule},
+		{name: "length is not a number", file: ".commitlintrc.json", content: `{"rules": {"header-max-length": [2, "always", "72"]}}`, code: i18n.LintConfigRule},
+		{
+			name:    "yaml dedent to an unopened level",
+			file:    ".commitlintrc.yaml",
+			content: "rules:\n    type-enum: [2, always, [feat]]\n  subject-case: [0]\n",
+			code:    i18n.LintYAMLSyntax,
+		},
+		{
+			name:    "yaml unexpected indent",
+			file:    ".commitlintrc.yaml",
+			content: "rules:\n  type-enum: [2, always, [feat]]\n      subject-case: [0]\n",
+			code:    i18n.LintYAMLSyntax,
+		},
+		{name: "yaml unclosed flow list", file: ".commitlintrc.yaml", content: "rules:\n  type-enum: [2, always, [feat]\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml unclosed flow map", file: ".commitlintrc.yml", content: "rules: {type-enum: [2, always, [feat]]\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml garbage after flow list", file: ".commitlintrc.yml", content: "rules:\n  type-enum: [2, always] x\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml line without key", file: ".commitlintrc.yaml", content: "rules:\n  type-enum\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml unterminated quoted key", file: ".commitlintrc.yaml", content: "rules:\n  'type-enum: [2]\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml unterminated string", file: ".commitlintrc.yaml", content: "rules:\n  type-enum: [2, 'always, [feat]]\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml top level list", file: ".commitlintrc.yaml", content: "- rules\n- extends\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml list item under a mapping", file: ".commitlintrc.yaml", content: "rules:\n  type-enum:\n    - 2\n    level: 2\n", code: i18n.LintYAMLSyntax},
+		{name: "yaml rule is not a list", file: ".commitlintrc.yml", content: "rules:\n  type-enum: 2\n", code: i18n.LintConfigRule},
+		{name: "package.json syntax", file: "package.json", content: `{"commitlint": {"rules": }`},
+		{name: "js unterminated rules", file: "commitlint.config.js", content: "module.exports = { rules: { 'type-enum': [2, 'always', ['feat'] }\n", code: i18n.LintConfigUnterminated},
+		{name: "js unterminated string", file: "commitlint.config.js", content: "module.exports = { rules: { 'type-enum: [2] } }\n", code: i18n.LintConfigUnterminated},
+		{name: "js unterminated comment", file: "commitlint.config.ts", content: "/* rules\nexport default { rules: {} }\n", code: i18n.LintConfigUnterminated},
+		{name: "js invalid object", file: "commitlint.config.mjs", content: "export default { rules: { 'type-enum': [2 'always'] } }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfig(t, tt.file, tt.content)
+			rules, path, err := LoadRules(dir, testTypes)
+			if i18n.CodeOf(err) != i18n.LintConfigParse {
+				t.Fatalf("want %s, got rules %+v, err %v", i18n.LintConfigParse, rules, err)
+			}
+			if tt.code != "" && !hasCode(err, tt.code) {
+				t.Errorf("want %s in the error chain, got %v", tt.code, err)
+			}
+			if filepath.Base(path) != tt.file {
+				t.Errorf("got config %s, want %s", path, tt.file)
+			}
+		})
+	}
+}
+
+func TestParseYAMLConfig(t *testing.T) {
+	src := `# 注释
+"quoted key": 'it''s # not a comment'
+plain: value with, commas
+numbers: [1, 2.5, -3]
+flow: {a: [x, "y"], 'b': ~}
+empty:
+nested:
+  - - a
+    - b
+  - key: value
+    other: [true, false]
+  -
+    - c
+`
+	got, err := parseYAMLConfig(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"quoted key": "it's # not a comment",
+		"plain":      "value with, commas",
+		"numbers":    []interface{}{1.0, 2.5, -3.0},
+		"flow":       map[string]interface{}{"a": []interface{}{"x", "y"}, "b": nil},
+		"empty":      nil,
+		"nested": []interface{}{
+			[]interface{}{"a", "b"},
+			map[string]interface{}{"key": "value", "other": []interface{}{true, false}},
+			[]interface{}{"c"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
@@ -0,0 +1,384 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// 规则名称，与commitlint的规则名称一致（scope-charset除外）
+const (
+	RuleTypeEmpty         = "type-empty"
+	RuleTypeEnum          = "type-enum"
+	RuleScopeCharset      = "scope-charset"
+	RuleScopeEnum         = "scope-enum"
+	RuleSubjectEmpty      = "subject-empty"
+	RuleSubjectFullStop   = "subject-full-stop"
+	RuleSubjectCase       = "subject-case"
+	RuleSubjectMaxLength  = "subject-max-length"
+	RuleHeaderMaxLength   = "header-max-length"
+	RuleBodyMaxLineLength = "body-max-line-length"
+)
+
+// 默认的长度限制
+const (
+	DefaultSubjectMaxLength  = 50
+	DefaultBodyMaxLineLength = 100
+)
+
+// DefaultSubjectCase 是主题默认不能使用的大小写格式（与commitlint的约定式配置相同）
+var DefaultSubjectCase = []string{"sentence-case", "start-case", "pascal-case", "upper-case"}
+
+// typeAliases 是模型常用的非标准类型到约定式提交类型的映射
+var typeAliases = map[string]string{
+	"feature":       "feat",
+	"features":      "feat",
+	"bug":           "fix",
+	"bugfix":        "fix",
+	"hotfix":        "fix",
+	"doc":           "docs",
+	"documentation": "docs",
+	"tests":         "test",
+	"testing":       "test",
+	"refactoring":   "refactor",
+	"performance":   "perf",
+	"styles":        "style",
+	"chores":        "chore",
+}
+
+// scopePattern 是合法的范围：小写字母、数字和._/-
+var scopePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*$`)
+
+// scopeInvalid 匹配范围中不允许的字符
+var scopeInvalid = regexp.MustCompile(`[^a-z0-9._/-]+`)
+
+// Rules 是commit message需要满足的规则，长度限制为0时不检查
+type Rules struct {
+	Types             []string `json:"types"`                // 允许的类型
+	Scopes            []string `json:"scopes,omitempty"`     // 允许的范围，为空时不检查
+	ScopesNever       bool     `json:"scopes_never"`         // 为true时Scopes是不允许的范围
+	SubjectMaxLength  int      `json:"subject_max_length"`   // 主题的最大字符数
+	SubjectCase       []string `json:"subject_case"`         // 主题的大小写格式，为空时不检查
+	SubjectCaseNever  bool     `json:"subject_case_never"`   // 为true时主题不能使用SubjectCase中的格式，否则必须使用其中之一
+	HeaderMaxLength   int      `json:"header_max_length"`    // 第一行（类型、范围和主题）的最大字符数
+	BodyMaxLineLength int      `json:"body_max_line_length"` // 正文每行的最大字符数
+}
+
+// DefaultRules 返回默认规则，types是允许的类型
+func DefaultRules(types []string) *Rules {
+	return &Rules{
+		Types:             types,
+		SubjectMaxLength:  DefaultSubjectMaxLength,
+		SubjectCase:       DefaultSubjectCase,
+		SubjectCaseNever:  true,
+		BodyMaxLineLength: DefaultBodyMaxLineLength,
+	}
+}
+
+// Message 是需要检查的commit message
+type Message struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Body     string
+	Breaking bool // 是否包含破坏性变更，第一行中会有!
+}
+
+// Header 返回约定式提交格式的第一行
+func (m *Message) Header() string {
+	header := m.Type
+	if m.Scope != "" {
+		header += "(" + m.Scope + ")"
+	}
+	if m.Breaking {
+		header += "!"
+	}
+	return header + ": " + m.Subject
+}
+
+// Violation 是一处不符合规则的地方
+type Violation struct {
+	Rule    string `json:"rule"`    // 规则名称
+	Message string `json:"message"` // 用界面语言描述的问题
+	Fixed   bool   `json:"fixed"`   // 是否已经自动修正
+
+	detail *i18n.Error // 用于以其他语言描述问题
+}
+
+// newViolation 创建一处违规
+func newViolation(rule string, fixed bool, code i18n.Code, args ...interface{}) Violation {
+	detail := i18n.New(code, args...)
+	return Violation{Rule: rule, Message: detail.Error(), Fixed: fixed, detail: detail}
+}
+
+// Localize 用指定的语言描述问题，发回模型重新生成时使用与提示词相同的语言
+func (v Violation) Localize(locale string) string {
+	if v.detail == nil {
+		return v.Message
+	}
+	return v.detail.Localize(locale)
+}
+
+// Unfixed 返回没有自动修正的违规
+func Unfixed(violations []Violation) []Violation {
+	unfixed := []Violation{}
+	for _, v := range violations {
+		if !v.Fixed {
+			unfixed = append(unfixed, v)
+		}
+	}
+	return unfixed
+}
+
+// Fix 按规则检查msg，能自动修正的问题（类型的大小写和别名、范围中的字符、主题末尾的句号、
+// 主题的大小写、正文过长的行）直接修改msg并标记为已修正，其余问题需要模型重新生成
+func (r *Rules) Fix(msg *Message) []Violation {
+	violations := []Violation{}
+	msg.Type = strings.TrimSpace(msg.Type)
+	msg.Scope = strings.TrimSpace(msg.Scope)
+	msg.Subject = strings.Join(strings.Fields(msg.Subject), " ")
+	msg.Body = strings.TrimSpace(msg.Body)
+
+	// 类型
+	if msg.Type == "" {
+		violations = append(violations, newViolation(RuleTypeEmpty, false, i18n.LintTypeEmpty))
+	} else if len(r.Types) > 0 && !contains(r.Types, msg.Type) {
+		fixed := r.fixType(msg.Type)
+		violations = append(violations, newViolation(RuleTypeEnum, fixed != "", i18n.LintTypeEnum, msg.Type, strings.Join(r.Types, ", ")))
+		if fixed != "" {
+			msg.Type = fixed
+		}
+	}
+
+	// 范围：只能包含小写字母、数字和._/-，无法修正为合法范围时去掉范围
+	if msg.Scope != "" && !scopePattern.MatchString(msg.Scope) {
+		violations = append(violations, newViolation(RuleScopeCharset, true, i18n.LintScopeCharset, msg.Scope))
+		scope := scopeInvalid.ReplaceAllString(strings.ToLower(msg.Scope), "-")
+		msg.Scope = strings.Trim(scope, "-./_")
+	}
+	// 不允许的范围：大小写不同时使用规则中的写法，否则去掉范围
+	if msg.Scope != "" && len(r.Scopes) > 0 && contains(r.Scopes, msg.Scope) == r.ScopesNever {
+		violations = append(violations, newViolation(RuleScopeEnum, true, i18n.LintScopeEnum, msg.Scope, strings.Join(r.Scopes, ", ")))
+		msg.Scope = r.fixScope(msg.Scope)
+	}
+
+	// 主题
+	if msg.Subject == "" {
+		violations = append(violations, newViolation(RuleSubjectEmpty, false, i18n.LintSubjectEmpty))
+		return append(violations, r.fixBody(msg)...)
+	}
+	if trimmed := strings.TrimRight(msg.Subject, ".。 "); trimmed != msg.Subject {
+		violations = append(violations, newViolation(RuleSubjectFullStop, true, i18n.LintSubjectFullStop))
+		msg.Subject = trimmed
+	}
+	// 主题只有句号时去掉句号后为空，交给模型重新生成
+	if msg.Subject == "" {
+		violations = append(violations, newViolation(RuleSubjectEmpty, false, i18n.LintSubjectEmpty))
+		return append(violations, r.fixBody(msg)...)
+	}
+	if v, ok := r.fixSubjectCase(msg); ok {
+		violations = append(violations, v)
+	}
+	if n := len([]rune(msg.Subject)); r.SubjectMaxLength > 0 && n > r.SubjectMaxLength {
+		violations = append(violations, newViolation(RuleSubjectMaxLength, false, i18n.LintSubjectMaxLength, n, r.SubjectMaxLength))
+	}
+	if n := len([]rune(msg.Header())); r.HeaderMaxLength > 0 && n > r.HeaderMaxLength {
+		violations = append(violations, newViolation(RuleHeaderMaxLength, false, i18n.LintHeaderMaxLength, n, r.HeaderMaxLength))
+	}
+
+	return append(violations, r.fixBody(msg)...)
+}
+
+// fixType 把大小写不对或使用别名的类型修正为允许的类型，无法修正时返回空字符串
+func (r *Rules) fixType(t string) string {
+	t = strings.ToLower(t)
+	if alias, ok := typeAliases[t]; ok {
+		t = alias
+	}
+	if contains(r.Types, t) {
+		return t
+	}
+	return ""
+}
+
+// fixScope 把不允许的范围修正为规则中大小写不同的范围，没有时返回空字符串
+func (r *Rules) fixScope(scope string) string {
+	if r.ScopesNever {
+		return ""
+	}
+	for _, allowed := range r.Scopes {
+		if strings.EqualFold(allowed, scope) {
+			return allowed
+		}
+	}
+	return ""
+}
+
+// fixSubjectCase 检查主题的大小写格式，违规时尽量修正。
+// 主题不以字母开头时（例如中文主题）不检查
+func (r *Rules) fixSubjectCase(msg *Message) (Violation, bool) {
+	first := []rune(msg.Subject)[0]
+	if len(r.SubjectCase) == 0 || !unicode.IsUpper(first) && !unicode.IsLower(first) {
+		return Violation{}, false
+	}
+
+	cases := strings.Join(r.SubjectCase, ", ")
+	if r.SubjectCaseNever {
+		if !matchesAny(msg.Subject, r.SubjectCase) {
+			return Violation{}, false
+		}
+		// 去掉大写的首字母或全大写，修正后仍然违规时交给模型重新生成
+		fixed := lowerFirst(msg.Subject)
+		if msg.Subject == strings.ToUpper(msg.Subject) {
+			fixed = strings.ToLower(msg.Subject)
+		}
+		ok := !matchesAny(fixed, r.SubjectCase)
+		if ok {
+			msg.Subject = fixed
+		}
+		return newViolation(RuleSubjectCase, ok, i18n.LintSubjectCaseNever, cases), true
+	}
+
+	if matchesAny(msg.Subject, r.SubjectCase) {
+		return Violation{}, false
+	}
+	// 只能修正为小写、大写和句首大写
+	for _, c := range r.SubjectCase {
+		if fixed := toCase(msg.Subject, c); fixed != "" {
+			msg.Subject = fixed
+			return newViolation(RuleSubjectCase, true, i18n.LintSubjectCaseAlways, cases), true
+		}
+	}
+	return newViolation(RuleSubjectCase, false, i18n.LintSubjectCaseAlways, cases), true
+}
+
+// fixBody 把正文中超过长度限制的行折行
+func (r *Rules) fixBody(msg *Message) []Violation {
+	if r.BodyMaxLineLength <= 0 || msg.Body == "" {
+		return nil
+	}
+
+	violations := []Violation{}
+	lines := strings.Split(msg.Body, "\n")
+	wrapped := make([]string, 0, len(lines))
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if n := len([]rune(line)); n > r.BodyMaxLineLength {
+			violations = append(violations, newViolation(RuleBodyMaxLineLength, true, i18n.LintBodyMaxLineLength, i+1, n, r.BodyMaxLineLength))
+			wrapped = append(wrapped, wrapLine(line, r.BodyMaxLineLength)...)
+			continue
+		}
+		wrapped = append(wrapped, line)
+	}
+	msg.Body = strings.Join(wrapped, "\n")
+	return violations
+}
+
+// wrapLine 把一行按单词折行为不超过width个字符的多行，单词本身过长（例如中文）时按字符拆分
+func wrapLine(line string, width int) []string {
+	lines := []string{}
+	current := []rune{}
+	for _, word := range strings.Fields(line) {
+		runes := []rune(word)
+		for len(runes) > 0 {
+			space := 0
+			if len(current) > 0 {
+				space = 1
+			}
+			if len(current)+space+len(runes) <= width {
+				if space == 1 {
+					current = append(current, ' ')
+				}
+				current = append(current, runes...)
+				break
+			}
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = []rune{}
+				continue
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+// matchesCase 判断s是否符合commitlint的大小写格式
+func matchesCase(s, c string) bool {
+	words := strings.Fields(s)
+	switch c {
+	case "lower-case", "lowercase":
+		return s == strings.ToLower(s)
+	case "upper-case", "uppercase":
+		return s == strings.ToUpper(s)
+	case "sentence-case", "sentencecase":
+		return s == toCase(s, c)
+	case "start-case", "startcase":
+		for _, word := range words {
+			if r := []rune(word)[0]; unicode.IsLetter(r) && !unicode.IsUpper(r) {
+				return false
+			}
+		}
+		return true
+	case "pascal-case", "pascalcase":
+		return len(words) == 1 && unicode.IsUpper([]rune(s)[0]) && !strings.ContainsAny(s, "-_")
+	case "camel-case", "camelcase":
+		return len(words) == 1 && unicode.IsLower([]rune(s)[0]) && !strings.ContainsAny(s, "-_")
+	case "kebab-case", "kebabcase":
+		return len(words) == 1 && s == strings.ToLower(s) && !strings.Contains(s, "_")
+	case "snake-case", "snakecase":
+		return len(words) == 1 && s == strings.ToLower(s) && !strings.Contains(s, "-")
+	}
+	return false
+}
+
+// matchesAny 判断s是否符合cases中的任意一种大小写格式
+func matchesAny(s string, cases []string) bool {
+	for _, c := range cases {
+		if matchesCase(s, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// toCase 把s转换为小写、大写或句首大写格式，不支持的格式返回空字符串
+func toCase(s, c string) string {
+	switch c {
+	case "lower-case", "lowercase":
+		return strings.ToLower(s)
+	case "upper-case", "uppercase":
+		return strings.ToUpper(s)
+	case "sentence-case", "sentencecase":
+		// 第一个单词首字母大写、其余字母小写，之后的单词保持不变
+		word := strings.Fields(s)[0]
+		runes := []rune(word)
+		return string(unicode.ToUpper(runes[0])) + strings.ToLower(string(runes[1:])) + s[len(word):]
+	}
+	return ""
+}
+
+// lowerFirst 把首字母改为小写，第一个单词是全大写的缩写（例如API）时不修改
+func lowerFirst(s string) string {
+	word := []rune(strings.Fields(s)[0])
+	if len(word) > 1 && string(word) == strings.ToUpper(string(word)) {
+		return s
+	}
+	return string(unicode.ToLower(word[0])) + s[len(string(word[0])):]
+}
+
+// contains 判断list中是否有s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
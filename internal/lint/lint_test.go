@@ -0,0 +1,82 @@
+package lint
+
+import "testing"
+
+// rulesOf 返回违规的规则名称和是否已修正
+func rulesOf(violations []Violation) map[string]bool {
+	rules := map[string]bool{}
+	for _, v := range violations {
+		rules[v.Rule] = v.Fixed
+	}
+	return rules
+}
+
+func TestFixSubjectOnlyFullStop(t *testing.T) {
+	for _, subject := range []string{".", "。", ". .", "..."} {
+		r := DefaultRules([]string{"feat", "fix"})
+		msg := &Message{Type: "feat", Subject: subject}
+		violations := r.Fix(msg)
+
+		rules := rulesOf(violations)
+		if fixed, ok := rules[RuleSubjectEmpty]; !ok || fixed {
+			t.Errorf("subject %q: want unfixed %s, got %+v", subject, RuleSubjectEmpty, violations)
+		}
+		if _, ok := rules[RuleSubjectCase]; ok {
+			t.Errorf("subject %q: unexpected %s after empty subject", subject, RuleSubjectCase)
+		}
+		if msg.Subject != "" {
+			t.Errorf("subject %q: want empty subject, got %q", subject, msg.Subject)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     Message
+		want    Message
+		unfixed []string
+	}{
+		{
+			name: "type alias and full stop",
+			msg:  Message{Type: "Feature", Subject: "add cache."},
+			want: Message{Type: "feat", Subject: "add cache"},
+		},
+		{
+			name: "sentence case",
+			msg:  Message{Type: "fix", Scope: "AI Client", Subject: "Fix crash"},
+			want: Message{Type: "fix", Scope: "ai-client", Subject: "fix crash"},
+		},
+		{
+			name:    "unknown type",
+			msg:     Message{Type: "update", Subject: "bump deps"},
+			want:    Message{Type: "update", Subject: "bump deps"},
+			unfixed: []string{RuleTypeEnum},
+		},
+		{
+			name:    "chinese subject too long",
+			msg:     Message{Type: "docs", Subject: "更新说明文档中关于模型下载、缓存目录、配置文件和命令行参数的全部章节以及示例输出、常见问题的解答和升级到新版本时需要注意的兼容性说明"},
+			want:    Message{Type: "docs", Subject: "更新说明文档中关于模型下载、缓存目录、配置文件和命令行参数的全部章节以及示例输出、常见问题的解答和升级到新版本时需要注意的兼容性说明"},
+			unfixed: []string{RuleSubjectMaxLength},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRules([]string{"feat", "fix", "docs"})
+			msg := tt.msg
+			unfixed := Unfixed(r.Fix(&msg))
+			if msg != tt.want {
+				t.Errorf("got %+v, want %+v", msg, tt.want)
+			}
+			if len(unfixed) != len(tt.unfixed) {
+				t.Fatalf("got unfixed %+v, want %v", unfixed, tt.unfixed)
+			}
+			for i, rule := range tt.unfixed {
+				if unfixed[i].Rule != rule {
+					t.Errorf("got unfixed %s, want %s", unfixed[i].Rule, rule)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package lint
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// yamlLine 是YAML中去掉注释后的一行
+type yamlLine struct {
+	indent int    // 缩进的空格数
+	text   string // 去掉缩进后的内容
+}
+
+// parseYAMLConfig 解析commitlint配置使用的YAML子集：缩进表示的映射和列表、
+// 行内的[...]和{...}、带引号或不带引号的标量，不支持锚点和多行字符串
+func parseYAMLConfig(src string) (map[string]interface{}, error) {
+	lines := []yamlLine{}
+	for _, raw := range strings.Split(src, "\n") {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, i18n.New(i18n.LintYAMLSyntax, lines[p.pos].text)
+	}
+	config, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, i18n.New(i18n.LintYAMLSyntax, lines[0].text)
+	}
+	return config, nil
+}
+
+// stripYAMLComment 去掉引号之外以#开头的注释
+func stripYAMLComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// yamlParser 按行解析YAML的块结构
+type yamlParser struct {
+	lines []yamlLine
+	pos   int // 当前的行
+}
+
+// parseBlock 解析缩进为indent的映射或列表
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// isYAMLItem 判断一行是否为列表项
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseSequence 解析以"- "开头的列表
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	list := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			// 列表项的内容在下面缩进更深的行中
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				list = append(list, nil)
+				continue
+			}
+			value, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+
+		// "- - a"和"- key: value"：把"- "之后的内容当作缩进更深的一行
+		itemIndent := line.indent + len(line.text) - len(rest)
+		if isYAMLItem(rest) || isYAMLKey(rest) {
+			p.lines[p.pos] = yamlLine{indent: itemIndent, text: rest}
+			value, err := p.parseBlock(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+
+		value, err := parseYAMLFlow(rest)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		p.pos++
+	}
+	return list, nil
+}
+
+// parseMapping 解析"key: value"形式的映射
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && !isYAMLItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, i18n.New(i18n.LintYAMLSyntax, line.text)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := parseYAMLFlow(rest)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+			continue
+		}
+
+		// 值在下面的行中：缩进更深的块，或者与键缩进相同的列表
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || next.indent == indent && isYAMLItem(next.text) {
+				value, err := p.parseBlock(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				mapping[key] = value
+				continue
+			}
+		}
+		mapping[key] = nil
+	}
+	return mapping, nil
+}
+
+// isYAMLKey 判断一行是否为映射的键
+func isYAMLKey(text string) bool {
+	_, _, ok := splitYAMLKey(text)
+	return ok
+}
+
+// splitYAMLKey 把"key: value"拆分为键和值，键可以带引号
+func splitYAMLKey(text string) (string, string, bool) {
+	if text[0] == '\'' || text[0] == '"' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end == -1 {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(rest[1:]), true
+	}
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	if i := strings.Index(text, ": "); i > 0 {
+		return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+	}
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimSpace(strings.TrimSuffix(text, ":")), "", true
+	}
+	return "", "", false
+}
+
+// parseYAMLFlow 解析一行中的值：[...]、{...}或标量
+func parseYAMLFlow(text string) (interface{}, error) {
+	// 不在[...]或{...}中的标量可以包含逗号等字符
+	if !strings.ContainsRune("[{'\"", rune(text[0])) {
+		return yamlScalar(text), nil
+	}
+	f := &yamlFlow{text: text}
+	value, err := f.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.pos < len(f.text) {
+		return nil, i18n.New(i18n.LintYAMLSyntax, text)
+	}
+	return value, nil
+}
+
+// yamlFlow 解析行内的值
+type yamlFlow struct {
+	text string
+	pos  int
+}
+
+// skipSpace 跳过空白
+func (f *yamlFlow) skipSpace() {
+	for f.pos < len(f.text) && (f.text[f.pos] == ' ' || f.text[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+// parseValue 解析一个值，不带引号的标量在,]}处结束
+func (f *yamlFlow) parseValue() (interface{}, error) {
+	f.skipSpace()
+	if f.pos >= len(f.text) {
+		return nil, nil
+	}
+	switch f.text[f.pos] {
+	case '[':
+		return f.parseList()
+	case '{':
+		return f.parseMap()
+	}
+	return f.parseScalar()
+}
+
+// parseList 解析[a, b, ...]
+func (f *yamlFlow) parseList() (interface{}, error) {
+	f.pos++ // [
+	list := []interface{}{}
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.text) {
+			return nil, i18n.New(i18n.LintYAMLSyntax, f.text)
+		}
+		if f.text[f.pos] == ']' {
+			f.pos++
+			return list, nil
+		}
+		value, err := f.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		if err := f.endItem(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMap 解析{key: value, ...}
+func (f *yamlFlow) parseMap() (interface{}, error) {
+	f.pos++ // {
+	mapping := map[string]interface{}{}
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.text) {
+			return nil, i18n.New(i18n.LintYAMLSyntax, f.text)
+		}
+		if f.text[f.pos] == '}' {
+			f.pos++
+			return mapping, nil
+		}
+		key, err := f.parseScalar()
+		if err != nil {
+			return nil, err
+		}
+		f.skipSpace()
+		if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+			return nil, i18n.New(i18n.LintYAMLSyntax, f.text)
+		}
+		f.pos++
+		value, err := f.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		mapping[scalarString(key)] = value
+		if err := f.endItem('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// endItem 跳过列表或映射中元素之后的逗号，遇到结束符时保留给调用者处理
+func (f *yamlFlow) endItem(close byte) error {
+	f.skipSpace()
+	if f.pos < len(f.text) && f.text[f.pos] == ',' {
+		f.pos++
+		return nil
+	}
+	if f.pos < len(f.text) && f.text[f.pos] == close {
+		return nil
+	}
+	return i18n.New(i18n.LintYAMLSyntax, f.text)
+}
+
+// parseScalar 解析带引号的字符串，或者到,]}:之前的不带引号的标量
+func (f *yamlFlow) parseScalar() (interface{}, error) {
+	quote := f.text[f.pos]
+	if quote == '\'' || quote == '"' {
+		var sb strings.Builder
+		for i := f.pos + 1; i < len(f.text); i++ {
+			ch := f.text[i]
+			switch {
+			case quote == '\'' && ch == '\'' && i+1 < len(f.text) && f.text[i+1] == '\'':
+				sb.WriteByte('\'')
+				i++
+			case quote == '"' && ch == '\\' && i+1 < len(f.text):
+				s, err := strconv.Unquote(`"` + f.text[i:i+2] + `"`)
+				if err != nil {
+					return nil, i18n.New(i18n.LintYAMLSyntax, f.text)
+				}
+				sb.WriteString(s)
+				i++
+			case ch == quote:
+				f.pos = i + 1
+				return sb.String(), nil
+			default:
+				sb.WriteByte(ch)
+			}
+		}
+		return nil, i18n.New(i18n.LintYAMLSyntax, f.text)
+	}
+
+	start := f.pos
+	for f.pos < len(f.text) && !strings.ContainsRune(",]}", rune(f.text[f.pos])) &&
+		!(f.text[f.pos] == ':' && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ')) {
+		f.pos++
+	}
+	return yamlScalar(strings.TrimSpace(f.text[start:f.pos])), nil
+}
+
+// yamlScalar 把不带引号的标量转换为与JSON解析结果相同的类型
+func yamlScalar(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "~", "":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	return s
+}
+
+// scalarString 把标量转换为字符串，用作映射的键
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rust17/AImmit/internal/cache"
+	"github.com/rust17/AImmit/internal/gguf"
+	"github.com/rust17/AImmit/internal/i18n"
+)
+
+// ManifestName 是模型目录中SHA-256清单的文件名，格式与sha256sum的输出相同
+const ManifestName = "SHA256SUMS"
+
+// Model 表示模型目录中的一个GGUF文件
+type Model struct {
+	Name     string         // 文件名
+	Path     string         // 文件的绝对路径
+	Dir      string         // 所在的模型目录
+	Size     int64          // 文件大小（字节）
+	Metadata *gguf.Metadata // GGUF元数据，读取失败时为nil
+	Err      error          // 读取元数据的错误
+}
+
+// Scan 列出dirs中的GGUF文件，不存在的目录会被跳过，同一个文件只列出一次
+func Scan(dirs []string) ([]Model, error) {
+	models := []Model{}
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.ModelsScan, dir)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, i18n.Wrap(err, i18n.ModelsScan, dir)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".gguf") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			model := Model{Name: entry.Name(), Path: path, Dir: dir}
+			if info, err := entry.Info(); err == nil {
+				model.Size = info.Size()
+			}
+			model.Metadata, model.Err = gguf.ReadFile(path)
+			models = append(models, model)
+		}
+	}
+	return models, nil
+}
+
+// Find 按文件名（可以省略.gguf扩展名）或路径查找模型，找不到时返回nil
+func Find(models []Model, name string) *Model {
+	path, _ := filepath.Abs(name)
+	for i, model := range models {
+		if model.Name == name || strings.TrimSuffix(model.Name, filepath.Ext(model.Name)) == name || model.Path == path {
+			return &models[i]
+		}
+	}
+	return nil
+}
+
+// ReadManifest 读取dir中的SHA-256清单，返回文件名到哈希的映射，清单不存在时返回nil
+func ReadManifest(dir string) (map[string]string, error) {
+	path := filepath.Join(dir, ManifestName)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, i18n.Wrap(err, i18n.ModelsManifest, path)
+	}
+	defer file.Close()
+
+	manifest := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 每行形如 "<sha256>  <文件名>"，二进制模式下文件名前有*
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		name := filepath.Base(strings.TrimPrefix(fields[1], "*"))
+		manifest[name] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, i18n.Wrap(err, i18n.ModelsManifest, path)
+	}
+	return manifest, nil
+}
+
+// 校验结果
+const (
+	StatusOK       = "ok"       // 哈希与清单一致
+	StatusMismatch = "mismatch" // 哈希与清单不一致
+	StatusUnlisted = "unlisted" // 清单中没有记录该文件
+	StatusMissing  = "missing"  // 清单中记录的文件不存在
+)
+
+// VerifyResult 是单个文件的校验结果
+type VerifyResult struct {
+	Name     string // 文件名
+	Dir      string // 所在的模型目录
+	Status   string // 校验结果
+	Expected string // 清单中记录的哈希
+	Actual   string // 实际的哈希
+}
+
+// Verify 用各目录中的SHA-256清单校验模型文件。
+// dirs中每个目录的清单里记录但不存在的文件也会被报告，即使目录中没有GGUF文件
+func Verify(models []Model, dirs []string) ([]VerifyResult, error) {
+	manifests := map[string]map[string]string{}
+	readManifest := func(dir string) (map[string]string, error) {
+		manifest, ok := manifests[dir]
+		if ok {
+			return manifest, nil
+		}
+		manifest, err := ReadManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+		manifests[dir] = manifest
+		return manifest, nil
+	}
+
+	results := []VerifyResult{}
+	for _, model := range models {
+		manifest, err := readManifest(model.Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		result := VerifyResult{Name: model.Name, Dir: model.Dir, Expected: manifest[model.Name]}
+		if result.Expected == "" {
+			result.Status = StatusUnlisted
+			results = append(results, result)
+			continue
+		}
+		sum, err := cache.HashFile(model.Path)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.ModelsHash, model.Path)
+		}
+		result.Actual = sum
+		result.Status = StatusOK
+		if sum != result.Expected {
+			result.Status = StatusMismatch
+		}
+		results = append(results, result)
+	}
+
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.ModelsScan, dir)
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		manifest, err := readManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(manifest))
+		for name := range manifest {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+				results = append(results, VerifyResult{Name: name, Dir: dir, Status: StatusMissing, Expected: manifest[name]})
+			}
+		}
+	}
+	return results, nil
+}
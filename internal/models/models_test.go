@@ -0,0 +1,148 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile 在dir中写入文件，返回文件路径
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sha256Hex 返回content的SHA-256
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "b.gguf", "fake model b")
+	writeFile(t, dir, "A.GGUF", "fake model a")
+	writeFile(t, dir, "notes.txt", "not a model")
+	if err := os.Mkdir(filepath.Join(dir, "sub.gguf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不存在的目录被跳过，重复的目录只列出一次
+	found, err := Scan([]string{dir, filepath.Join(dir, "missing"), dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, model := range found {
+		names = append(names, model.Name)
+		if model.Dir != dir || model.Path != filepath.Join(dir, model.Name) {
+			t.Errorf("%s: unexpected path %s in %s", model.Name, model.Path, model.Dir)
+		}
+		// 不是有效的GGUF文件，元数据读取失败
+		if model.Metadata != nil || model.Err == nil {
+			t.Errorf("%s: want a metadata error, got %+v", model.Name, model)
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"A.GGUF", "b.gguf"}) {
+		t.Errorf("got %v", names)
+	}
+	if found[1].Size != int64(len("fake model b")) {
+		t.Errorf("got size %d", found[1].Size)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	found := []Model{
+		{Name: "qwen.gguf", Path: filepath.Join(dir, "qwen.gguf"), Dir: dir},
+		{Name: "llama.gguf", Path: filepath.Join(dir, "llama.gguf"), Dir: dir},
+	}
+	for name, want := range map[string]string{
+		"llama.gguf":                     "llama.gguf",
+		"llama":                          "llama.gguf",
+		filepath.Join(dir, "qwen.gguf"):  "qwen.gguf",
+		filepath.Join(dir, "other.gguf"): "",
+		"qwen.bin":                       "",
+	} {
+		model := Find(found, name)
+		got := ""
+		if model != nil {
+			got = model.Name
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	if manifest, err := ReadManifest(dir); manifest != nil || err != nil {
+		t.Fatalf("no manifest: got %v, %v", manifest, err)
+	}
+
+	writeFile(t, dir, ManifestName, "# comment\nABCDEF  a.gguf\n012345 *sub/b.gguf\n\nmalformed line here\n")
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a.gguf": "abcdef", "b.gguf": "012345"}; !reflect.DeepEqual(manifest, want) {
+		t.Errorf("got %v, want %v", manifest, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "ok.gguf", "good model")
+	writeFile(t, dir, "bad.gguf", "corrupted model")
+	writeFile(t, dir, "extra.gguf", "unlisted model")
+	writeFile(t, dir, ManifestName, sha256Hex("good model")+"  ok.gguf\n"+
+		sha256Hex("original model")+"  bad.gguf\n"+
+		sha256Hex("deleted model")+"  gone.gguf\n")
+	// 没有GGUF文件的目录中的清单也要读取
+	emptyDir := t.TempDir()
+	writeFile(t, emptyDir, ManifestName, sha256Hex("lost model")+"  lost.gguf\n")
+
+	found, err := Scan([]string{dir, emptyDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Verify(found, []string{dir, emptyDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, result := range results {
+		got[result.Name] = result.Status
+		if result.Status == StatusMismatch && (result.Expected != sha256Hex("original model") || result.Actual != sha256Hex("corrupted model")) {
+			t.Errorf("mismatch: got expected %s, actual %s", result.Expected, result.Actual)
+		}
+	}
+	want := map[string]string{
+		"ok.gguf":    StatusOK,
+		"bad.gguf":   StatusMismatch,
+		"extra.gguf": StatusUnlisted,
+		"gone.gguf":  StatusMissing,
+		"lost.gguf":  StatusMissing,
+	}
+	if len(results) != len(want) || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// 只校验指定的模型时不报告缺失的文件
+	results, err = Verify([]Model{*Find(found, "ok")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != StatusOK {
+		t.Errorf("got %+v", results)
+	}
+}
@@ -6,14 +6,57 @@ import (
 	"strings"
 
 	"github.com/rust17/AImmit/internal/ai"
+	"github.com/rust17/AImmit/internal/i18n"
+	"github.com/rust17/AImmit/internal/lint"
 )
 
 // Client 是总结格式化的客户端
-type Client struct{}
+type Client struct {
+	lang string // 输出中固定文字使用的语言
+}
 
 // NewClient 创建一个新的总结客户端
 func NewClient() *Client {
-	return &Client{}
+	return &Client{lang: ai.DefaultLang}
+}
+
+// SetLang 设置输出中固定文字使用的语言，与commit message的语言一致
+func (c *Client) SetLang(lang string) {
+	c.lang = lang
+}
+
+// labels 是输出中的固定文字
+type labels struct {
+	scope    string // 范围，%s
+	breaking string // 文本格式中的破坏性变更提示
+	footer   string // BREAKING CHANGE脚注的说明
+}
+
+// langLabels 是各语言的固定文字，双语模式下使用正文的语言
+var langLabels = map[string]labels{
+	ai.LangZh: {
+		scope:    "范围: %s\n",
+		breaking: "\n⚠️ 包含破坏性变更\n",
+		footer:   "此提交包含破坏性变更",
+	},
+	ai.LangEn: {
+		scope:    "Scope: %s\n",
+		breaking: "\n⚠️ Contains breaking changes\n",
+		footer:   "this commit contains breaking changes",
+	},
+	ai.LangJa: {
+		scope:    "範囲: %s\n",
+		breaking: "\n⚠️ 破壊的変更を含みます\n",
+		footer:   "このコミットには破壊的変更が含まれます",
+	},
+}
+
+// labels 返回当前语言的固定文字，不支持的语言使用默认语言
+func (c *Client) labels() labels {
+	if l, ok := langLabels[ai.BodyLang(c.lang)]; ok {
+		return l
+	}
+	return langLabels[ai.DefaultLang]
 }
 
 // FormatCommitMessage 根据指定格式输出commit message
@@ -26,8 +69,33 @@ func (c *Client) FormatCommitMessage(commitMsg *ai.CommitMessage, format string)
 	case "conventional":
 		return c.formatCommitAsConventional(commitMsg), nil
 	default:
-		return "", fmt.Errorf("不支持的输出格式: %s", format)
+		return "", i18n.New(i18n.SummarizerUnsupportedFormat, format)
+	}
+}
+
+// FormatCandidates 以编号列表输出多条候选commit message，编号从1开始
+func (c *Client) FormatCandidates(candidates []*ai.CommitMessage, format string) (string, error) {
+	if strings.ToLower(format) == "json" {
+		outputs := make([]jsonOutput, 0, len(candidates))
+		for _, commitMsg := range candidates {
+			outputs = append(outputs, c.toJSONOutput(commitMsg))
+		}
+		jsonBytes, err := json.MarshalIndent(outputs, "", "  ")
+		if err != nil {
+			return "", i18n.Wrap(err, i18n.SummarizerMarshalJSON)
+		}
+		return string(jsonBytes), nil
 	}
+
+	parts := make([]string, 0, len(candidates))
+	for i, commitMsg := range candidates {
+		output, err := c.FormatCommitMessage(commitMsg, format)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("[%d] %s", i+1, strings.TrimRight(output, "\n")))
+	}
+	return strings.Join(parts, "\n\n"), nil
 }
 
 // formatCommitAsText 以纯文本格式输出commit message
@@ -36,7 +104,7 @@ func (c *Client) formatCommitAsText(commitMsg *ai.CommitMessage) string {
 
 	sb.WriteString(fmt.Sprintf("%s: %s\n", commitMsg.Type, commitMsg.Subject))
 	if commitMsg.Scope != "" {
-		sb.WriteString(fmt.Sprintf("范围: %s\n", commitMsg.Scope))
+		sb.WriteString(fmt.Sprintf(c.labels().scope, commitMsg.Scope))
 	}
 
 	if commitMsg.Body != "" {
@@ -44,37 +112,46 @@ func (c *Client) formatCommitAsText(commitMsg *ai.CommitMessage) string {
 	}
 
 	if commitMsg.BreakingChanges {
-		sb.WriteString("\n⚠️ 包含破坏性变更\n")
+		sb.WriteString(c.labels().breaking)
 	}
 
 	return sb.String()
 }
 
-// formatCommitAsJSON 以JSON格式输出commit message
-func (c *Client) formatCommitAsJSON(commitMsg *ai.CommitMessage) (string, error) {
-	// 创建一个包含所有信息的结构体
-	type jsonOutput struct {
-		Type            string `json:"type"`
-		Scope           string `json:"scope,omitempty"`
-		Subject         string `json:"subject"`
-		Body            string `json:"body,omitempty"`
-		BreakingChanges bool   `json:"breaking_changes"`
-		Conventional    string `json:"conventional"`
-	}
+// jsonOutput 是JSON格式输出的结构体，包含所有信息
+type jsonOutput struct {
+	Type            string               `json:"type"`
+	Scope           string               `json:"scope,omitempty"`
+	Subject         string               `json:"subject"`
+	Body            string               `json:"body,omitempty"`
+	BreakingChanges bool                 `json:"breaking_changes"`
+	Conventional    string               `json:"conventional"`
+	Params          *ai.GenerationParams `json:"params,omitempty"`
+	Violations      []lint.Violation     `json:"violations,omitempty"`
+}
 
-	output := jsonOutput{
+// toJSONOutput 把commit message转换为JSON输出结构体
+func (c *Client) toJSONOutput(commitMsg *ai.CommitMessage) jsonOutput {
+	return jsonOutput{
 		Type:            commitMsg.Type,
 		Scope:           commitMsg.Scope,
 		Subject:         commitMsg.Subject,
 		Body:            commitMsg.Body,
 		BreakingChanges: commitMsg.BreakingChanges,
 		Conventional:    c.formatCommitAsConventional(commitMsg),
+		Params:          commitMsg.Params,
+		Violations:      commitMsg.Violations,
 	}
+}
+
+// formatCommitAsJSON 以JSON格式输出commit message
+func (c *Client) formatCommitAsJSON(commitMsg *ai.CommitMessage) (string, error) {
+	output := c.toJSONOutput(commitMsg)
 
 	// 序列化为JSON
 	jsonBytes, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("序列化JSON失败: %w", err)
+		return "", i18n.Wrap(err, i18n.SummarizerMarshalJSON)
 	}
 
 	return string(jsonBytes), nil
@@ -112,7 +189,7 @@ func (c *Client) formatCommitAsConventional(commitMsg *ai.CommitMessage) string
 		} else {
 			sb.WriteString("\n")
 		}
-		sb.WriteString("BREAKING CHANGE: 此提交包含破坏性变更")
+		sb.WriteString("BREAKING CHANGE: " + c.labels().footer)
 	}
 
 	return sb.String()
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// spinnerFrames 是等待动画的帧
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner 在等待模型输出时显示等待动画，收到输出后改为实时显示生成的内容
+type Spinner struct {
+	out      *os.File
+	terminal bool // 只有输出到终端时才显示动画
+
+	mu       sync.Mutex
+	msg      string
+	running  bool // 动画是否正在显示
+	streamed bool // 是否已经输出过生成的内容
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewSpinner 创建输出到out的等待动画
+func NewSpinner(out *os.File) *Spinner {
+	return &Spinner{out: out, terminal: IsTerminal(out)}
+}
+
+// IsTerminal 判断f是否是终端
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start 开始显示带提示信息的等待动画，不是终端时只输出一次提示信息
+func (s *Spinner) Start(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msg = msg
+	s.streamed = false
+	if !s.terminal {
+		fmt.Fprintln(s.out, msg)
+		return
+	}
+
+	s.running = true
+	s.done = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.spin(s.done, s.stopped)
+}
+
+// spin 循环绘制动画帧，直到done被关闭
+func (s *Spinner) spin(done, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		s.mu.Lock()
+		if s.running {
+			fmt.Fprintf(s.out, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], s.msg)
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// clear 停止动画并清除动画所在的行，调用方需持有锁
+func (s *Spinner) clear() {
+	if !s.running {
+		return
+	}
+	s.running = false
+	close(s.done)
+	fmt.Fprint(s.out, "\r\033[K")
+}
+
+// Write 实时输出生成的内容，第一次输出时清除等待动画
+func (s *Spinner) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clear()
+	if len(p) > 0 {
+		s.streamed = true
+	}
+	return s.out.Write(p)
+}
+
+// Stop 停止等待动画，输出过生成内容时补一个换行
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	s.clear()
+	stopped := s.stopped
+	if s.streamed {
+		fmt.Fprintln(s.out)
+		s.streamed = false
+	}
+	s.mu.Unlock()
+
+	if stopped != nil {
+		<-stopped
+	}
+}
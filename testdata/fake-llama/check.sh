@@ -0,0 +1,66 @@
+#!/bin/sh
+# 用假的llama-cli验证aimmit调用llama-cli的流程，不需要模型文件：
+#   sh testdata/fake-llama/check.sh
+set -eu
+
+dir=$(cd "$(dirname "$0")" && pwd)
+root=$(cd "$dir/../.." && pwd)
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+go build -o "$tmp/aimmit" "$root/cmd/aimmit"
+
+# 准备一个有暂存更改的仓库
+git init -q "$tmp/repo"
+echo "hello" > "$tmp/repo/hello.txt"
+git -C "$tmp/repo" add hello.txt
+
+run() {
+	"$tmp/aimmit" --repo "$tmp/repo" --llama-c-path "$dir" --model-path "$tmp/model.gguf" --stream=false "$@" 2>&1
+}
+
+fail() {
+	echo "FAIL: $*"
+	exit 1
+}
+
+# 终止标记被拆分在两次输出中，aimmit需要识别并结束不会自行退出的进程
+start=$(date +%s)
+output=$(run) || fail "正常生成失败: $output"
+case "$output" in
+*"feat(ai): add fake llama-cli"*) ;;
+*) fail "未解析出commit message: $output" ;;
+esac
+case "$output" in
+*"garbage after stop"*) fail "终止标记之后的内容没有被丢弃: $output" ;;
+esac
+[ $(($(date +%s) - start)) -lt 30 ] || fail "遇到终止标记后没有结束llama-cli"
+echo "ok: 跨块的终止标记"
+
+# 没有终止标记、正常退出
+output=$(FAKE_LLAMA_STOP= run) || fail "正常退出时生成失败: $output"
+case "$output" in
+*"feat(ai): add fake llama-cli"*) echo "ok: 正常退出" ;;
+*) fail "正常退出时未解析出commit message: $output" ;;
+esac
+
+# 关闭回退时，异常退出报告标准错误输出
+if output=$(FAKE_LLAMA_RESPONSE= FAKE_LLAMA_EXIT=3 FAKE_LLAMA_STDERR="failed to load model" run --retries 0 --fallback=false); then
+	fail "异常退出时没有报错: $output"
+fi
+case "$output" in
+*"failed to load model"*) echo "ok: 报告标准错误输出" ;;
+*) fail "错误信息中没有标准错误输出: $output" ;;
+esac
+
+# 默认开启回退时，异常退出改用启发式规则生成，并在params.fallback中记录原因
+output=$(FAKE_LLAMA_RESPONSE= FAKE_LLAMA_EXIT=3 FAKE_LLAMA_STDERR="failed to load model" run --retries 0 --format json) ||
+	fail "回退到启发式规则时生成失败: $output"
+case "$output" in
+*'"backend": "heuristic"'*) ;;
+*) fail "没有回退到启发式规则: $output" ;;
+esac
+case "$output" in
+*'"fallback": "'*"failed to load model"*) echo "ok: 回退到启发式规则" ;;
+*) fail "params.fallback中没有失败原因: $output" ;;
+esac